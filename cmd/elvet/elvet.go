@@ -0,0 +1,681 @@
+// Package elvet provides static analysis on GoEL expressions.
+//
+// The analyzer verifies constant expressions against the static type of their
+// root argument. Fields which do not exist, key literals which do not fit a map
+// and result types which do not match the function cause a diagnostic. Content
+// behind interfaces and recursive descents can not be verified. Expressions
+// with operators or functions get a syntax check only. Methods of el.Expr are
+// checked when their receiver is an el.MustCompile call with a constant.
+//
+// Use the goel-vet command with go vet, as in
+//
+//	go vet -vettool=$(which goel-vet) ./...
+package elvet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+
+	"github.com/pascaldekloe/goe/el"
+)
+
+// Analyzer checks GoEL expressions.
+var Analyzer = &analysis.Analyzer{
+	Name:     "goel",
+	Doc:      "check GoEL expressions against the type of their root",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+const (
+	elPath   = "github.com/pascaldekloe/goe/el"
+	restPath = "github.com/pascaldekloe/goe/rest"
+)
+
+// signature has the argument positions of a function, with -1 for none.
+type signature struct {
+	expr, root, value int
+	// result is the requirement for the result type, if any.
+	result func(*types.Basic) bool
+}
+
+func isBool(t *types.Basic) bool {
+	return t.Info()&types.IsBoolean != 0
+}
+
+func isInt(t *types.Basic) bool {
+	return t.Info()&(types.IsInteger|types.IsUnsigned) == types.IsInteger
+}
+
+func isUint(t *types.Basic) bool {
+	return t.Info()&types.IsUnsigned != 0 && t.Kind() != types.Uintptr
+}
+
+func isFloat(t *types.Basic) bool {
+	return t.Info()&types.IsFloat != 0
+}
+
+func isComplex(t *types.Basic) bool {
+	return t.Info()&types.IsComplex != 0
+}
+
+func isString(t *types.Basic) bool {
+	return t.Info()&types.IsString != 0
+}
+
+// elFuncs has the signature per function name in package el.
+var elFuncs = map[string]signature{
+	"Bool":        {0, 1, -1, isBool},
+	"Int":         {0, 1, -1, isInt},
+	"Uint":        {0, 1, -1, isUint},
+	"Float":       {0, 1, -1, isFloat},
+	"Complex":     {0, 1, -1, isComplex},
+	"String":      {0, 1, -1, isString},
+	"Bools":       {0, 1, -1, isBool},
+	"Ints":        {0, 1, -1, isInt},
+	"Uints":       {0, 1, -1, isUint},
+	"Floats":      {0, 1, -1, isFloat},
+	"Complexes":   {0, 1, -1, isComplex},
+	"Strings":     {0, 1, -1, isString},
+	"Any":         {0, 1, -1, nil},
+	"Get":         {0, 1, -1, nil},
+	"GetAll":      {0, 1, -1, nil},
+	"Explain":     {0, 1, -1, nil},
+	"Locate":      {0, 1, -1, nil},
+	"Assign":      {1, 0, 2, nil},
+	"AssignText":  {1, 0, -1, nil},
+	"Delete":      {1, 0, -1, nil},
+	"Append":      {1, 0, -1, nil},
+	"Insert":      {1, 0, -1, nil},
+	"Redact":      {1, 0, -1, nil},
+	"RedactWith":  {2, 0, -1, nil},
+	"Project":     {1, 0, -1, nil},
+	"ProjectMap":  {1, 0, -1, nil},
+	"Compile":     {0, -1, -1, nil},
+	"MustCompile": {0, -1, -1, nil},
+}
+
+// exprMethods has the signature per method name of el.Expr. The expression
+// is in the receiver, which is resolved from a MustCompile call only.
+var exprMethods = map[string]signature{
+	"Bool":       {-1, 0, -1, isBool},
+	"Int":        {-1, 0, -1, isInt},
+	"Uint":       {-1, 0, -1, isUint},
+	"Float":      {-1, 0, -1, isFloat},
+	"Complex":    {-1, 0, -1, isComplex},
+	"String":     {-1, 0, -1, isString},
+	"Bools":      {-1, 0, -1, isBool},
+	"Ints":       {-1, 0, -1, isInt},
+	"Uints":      {-1, 0, -1, isUint},
+	"Floats":     {-1, 0, -1, isFloat},
+	"Complexes":  {-1, 0, -1, isComplex},
+	"Strings":    {-1, 0, -1, isString},
+	"Any":        {-1, 0, -1, nil},
+	"Explain":    {-1, 0, -1, nil},
+	"Locate":     {-1, 0, -1, nil},
+	"Assign":     {-1, 0, 1, nil},
+	"AssignText": {-1, 0, -1, nil},
+	"Delete":     {-1, 0, -1, nil},
+	"Append":     {-1, 0, -1, nil},
+	"Insert":     {-1, 0, -1, nil},
+}
+
+// modifiers has the function names in package el which require a path.
+var modifiers = map[string]bool{
+	"Assign":     true,
+	"AssignText": true,
+	"Delete":     true,
+	"Append":     true,
+	"Insert":     true,
+	"Redact":     true,
+	"RedactWith": true,
+}
+
+// projections has the function names in package el which select by path.
+var projections = map[string]bool{
+	"Project":    true,
+	"ProjectMap": true,
+}
+
+// variadics has the function names in package el which take any number of
+// expressions from the expression position onwards.
+var variadics = map[string]bool{
+	"Redact":     true,
+	"RedactWith": true,
+	"Project":    true,
+	"ProjectMap": true,
+}
+
+// restFuncs has the signature per function name in package rest.
+var restFuncs = map[string]signature{
+	"NewCRUD": {1, -1, -1, nil},
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if strings.TrimSuffix(pass.Pkg.Path(), "_test") == elPath {
+		return nil, nil // tests on malformed expressions
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		f, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok || f.Pkg() == nil {
+			return
+		}
+		if recv := f.Type().(*types.Signature).Recv(); recv != nil {
+			sig, ok := exprMethods[f.Name()]
+			if !ok || f.Pkg().Path() != elPath || !isExpr(recv.Type()) {
+				return
+			}
+			if arg := compiledArg(pass, call); arg != nil {
+				check(pass, call, f, sig, arg)
+			}
+			return
+		}
+
+		var sig signature
+		ok = false
+		switch f.Pkg().Path() {
+		case elPath:
+			sig, ok = elFuncs[f.Name()]
+		case restPath:
+			sig, ok = restFuncs[f.Name()]
+		}
+		if !ok || sig.expr >= len(call.Args) {
+			return
+		}
+
+		args := call.Args[sig.expr : sig.expr+1]
+		if f.Pkg().Path() == elPath && variadics[f.Name()] && !call.Ellipsis.IsValid() {
+			args = call.Args[sig.expr:]
+		}
+		for _, arg := range args {
+			check(pass, call, f, sig, arg)
+		}
+	})
+	return nil, nil
+}
+
+// isExpr returns whether t is el.Expr, or a pointer to it.
+func isExpr(t types.Type) bool {
+	named, ok := deref(t).(*types.Named)
+	return ok && named.Obj().Name() == "Expr" && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == elPath
+}
+
+// compiledArg returns the expression argument of the el.MustCompile call which
+// is the receiver of call, with nil for other receivers.
+func compiledArg(pass *analysis.Pass, call *ast.CallExpr) ast.Expr {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	recv, ok := ast.Unparen(sel.X).(*ast.CallExpr)
+	if !ok || len(recv.Args) == 0 {
+		return nil
+	}
+	f, ok := typeutil.Callee(pass.TypesInfo, recv).(*types.Func)
+	if !ok || f.Pkg() == nil || f.Pkg().Path() != elPath || f.Name() != "MustCompile" || f.Type().(*types.Signature).Recv() != nil {
+		return nil
+	}
+	return recv.Args[0]
+}
+
+// check verifies the expression in arg of call to f. A negative expression
+// position in sig means that arg is from the receiver of f.
+func check(pass *analysis.Pass, call *ast.CallExpr, f *types.Func, sig signature, arg ast.Expr) {
+	tv, ok := pass.TypesInfo.Types[arg]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	expr := constant.StringVal(tv.Value)
+	x, err := el.Compile(expr)
+	if err != nil {
+		if sig.expr >= 0 {
+			pass.Reportf(arg.Pos(), "%s", err)
+		} // else reported on the MustCompile call
+		return
+	}
+	if !x.IsPath() {
+		if f.Pkg().Path() == elPath {
+			switch {
+			case modifiers[f.Name()]:
+				pass.Reportf(arg.Pos(), "GoEL %q: modification applies to paths only", expr)
+			case projections[f.Name()]:
+				pass.Reportf(arg.Pos(), "GoEL %q: projection applies to paths only", expr)
+			}
+		}
+		return // syntax check only
+	}
+	if sig.root < 0 || sig.root >= len(call.Args) {
+		return
+	}
+
+	results, reason := resolve(x.Segments(), pass.TypesInfo.TypeOf(call.Args[sig.root]))
+	switch {
+	case reason != "":
+		pass.Reportf(arg.Pos(), "GoEL %q: %s", expr, reason)
+	case results == nil:
+		break // unknown
+	case sig.result != nil:
+		if !anyBasic(results, sig.result) {
+			pass.Reportf(arg.Pos(), "GoEL %q: el.%s does not apply to result type %s", expr, f.Name(), typeList(results))
+		}
+	case sig.value >= 0 && sig.value < len(call.Args):
+		if !anyAssignable(results, pass.TypesInfo.TypeOf(call.Args[sig.value])) {
+			pass.Reportf(call.Args[sig.value].Pos(), "GoEL %q: value does not apply to type %s", expr, typeList(results))
+		}
+	}
+}
+
+// anyBasic returns whether any of the types has an underlying basic type which
+// passes the requirement.
+func anyBasic(a []types.Type, requirement func(*types.Basic) bool) bool {
+	for _, t := range a {
+		if b, ok := t.Underlying().(*types.Basic); ok && requirement(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyAssignable returns whether a value of type v applies to any of the types,
+// conform el.Assign.
+func anyAssignable(a []types.Type, v types.Type) bool {
+	if v == nil {
+		return true
+	}
+	v = deref(v)
+	if types.IsInterface(v) {
+		return true // dynamic
+	}
+	if b, ok := v.(*types.Basic); ok && b.Kind() == types.UntypedNil {
+		return true
+	}
+	v = types.Default(v)
+
+	for _, t := range a {
+		if types.AssignableTo(v, t) || types.ConvertibleTo(v, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeList returns the notation of a.
+func typeList(a []types.Type) string {
+	names := make([]string, len(a))
+	for i, t := range a {
+		names[i] = t.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// deref returns the element type of pointers, recursively.
+func deref(t types.Type) types.Type {
+	for {
+		p, ok := t.Underlying().(*types.Pointer)
+		if !ok {
+			return t
+		}
+		t = p.Elem()
+	}
+}
+
+// resolve returns the types path may result in on root. Results are nil for
+// unknown, and reason is set for no match.
+func resolve(path []el.Segment, root types.Type) (results []types.Type, reason string) {
+	if root == nil {
+		return nil, ""
+	}
+
+	track := []types.Type{root}
+	for _, c := range path {
+		for i, t := range track {
+			t = deref(t)
+			if types.IsInterface(t) {
+				return nil, ""
+			}
+			track[i] = t
+		}
+
+		var next []types.Type
+		switch {
+		case c.Selection == "**":
+			return nil, ""
+		case c.Selection == "":
+			next = track
+		default:
+			for _, t := range track {
+				a, why := selectField(t, c.Selection)
+				if why != "" && reason == "" {
+					reason = why
+				}
+				next = append(next, a...)
+			}
+		}
+
+		if c.Key != "" && len(next) != 0 {
+			track, next = next, nil
+			reason = ""
+			for _, t := range track {
+				t = deref(t)
+				if types.IsInterface(t) {
+					return nil, ""
+				}
+				e, why := selectKey(t, c.Key)
+				if why != "" && reason == "" {
+					reason = why
+				}
+				if e != nil {
+					next = append(next, e)
+				}
+			}
+		}
+
+		if len(next) == 0 {
+			if reason == "" {
+				reason = "no match on type " + typeList(track)
+			}
+			return nil, reason
+		}
+		reason = ""
+		track = next
+	}
+
+	for i, t := range track {
+		t = deref(t)
+		if types.IsInterface(t) {
+			return nil, ""
+		}
+		track[i] = t
+	}
+	return track, ""
+}
+
+// selectField returns the types matching selection on t, with a reason for
+// none.
+func selectField(t types.Type, selection string) ([]types.Type, string) {
+	s, isStruct := t.Underlying().(*types.Struct)
+
+	if i := strings.IndexByte(selection, '('); i > 0 {
+		return selectMethod(t, selection[:i], selection[i+1:len(selection)-1])
+	}
+
+	if selection[0] == '@' {
+		i := strings.IndexByte(selection, ':')
+		key, name := selection[1:i], selection[i+1:]
+		wildcard := name == "*"
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+
+		switch u := t.Underlying().(type) {
+		case *types.Struct:
+			if wildcard {
+				return fieldTypes(u), ""
+			}
+			if f := taggedField(u, key, name); f != nil {
+				return []types.Type{f}, ""
+			}
+			return nil, "type " + t.String() + " has no field with " + key + " name " + strconv.Quote(name)
+		case *types.Map:
+			return []types.Type{u.Elem()}, ""
+		case *types.Slice:
+			return []types.Type{u.Elem()}, ""
+		case *types.Array:
+			return []types.Type{u.Elem()}, ""
+		}
+		return nil, "type " + t.String() + " has no fields"
+	}
+
+	if !isStruct {
+		return nil, "type " + t.String() + " has no fields"
+	}
+	if selection == "*" {
+		return fieldTypes(s), ""
+	}
+	if f := lookupField(s, selection); f != nil {
+		return []types.Type{f}, ""
+	}
+	return nil, "type " + t.String() + " has no field " + selection
+}
+
+// selectMethod returns the result type of the invocation of method name with
+// args on t, with a reason for none. Pointer receivers are included.
+func selectMethod(t types.Type, name, args string) ([]types.Type, string) {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, name)
+	f, ok := obj.(*types.Func)
+	if !ok {
+		return nil, "type " + t.String() + " has no method " + name
+	}
+	sig := f.Type().(*types.Signature)
+	switch n := argCount(args); {
+	case sig.Variadic():
+		return nil, "method " + name + " of type " + t.String() + " is variadic"
+	case sig.Params().Len() != n:
+		return nil, "method " + name + " of type " + t.String() + " takes " + strconv.Itoa(sig.Params().Len()) + " arguments, not " + strconv.Itoa(n)
+	}
+	res := sig.Results()
+	errorType := types.Universe.Lookup("error").Type()
+	switch {
+	case res.Len() == 0 || types.Identical(res.At(0).Type(), errorType):
+		break
+	case res.Len() == 1:
+		return []types.Type{res.At(0).Type()}, ""
+	case res.Len() == 2 && types.Identical(res.At(1).Type(), errorType):
+		return []types.Type{res.At(0).Type()}, ""
+	}
+	return nil, "method " + name + " of type " + t.String() + " does not return one value, other than an error, optionally followed by an error"
+}
+
+// argCount returns the number of comma-separated literals in args.
+func argCount(args string) int {
+	if strings.TrimSpace(args) == "" {
+		return 0
+	}
+	n := 1
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case ',':
+			n++
+		case '"', '\'', '`':
+			quote := args[i]
+			for i++; i < len(args) && args[i] != quote; i++ {
+				if args[i] == '\\' && quote != '`' {
+					i++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// fieldTypes returns the type of each field in s.
+func fieldTypes(s *types.Struct) []types.Type {
+	a := make([]types.Type, s.NumFields())
+	for i := range a {
+		a[i] = s.Field(i).Type()
+	}
+	return a
+}
+
+// lookupField returns the type of the field with name in s, including promoted
+// fields, with nil for none. Ambiguous names have no match, conform reflect.
+func lookupField(s *types.Struct, name string) types.Type {
+	level := []*types.Struct{s}
+	seen := make(map[*types.Struct]bool)
+	for len(level) != 0 {
+		var next []*types.Struct
+		var match []types.Type
+		for _, s := range level {
+			if seen[s] {
+				continue
+			}
+			seen[s] = true
+
+			for i := 0; i < s.NumFields(); i++ {
+				f := s.Field(i)
+				if f.Name() == name {
+					match = append(match, f.Type())
+				}
+				if f.Embedded() {
+					if e, ok := deref(f.Type()).Underlying().(*types.Struct); ok {
+						next = append(next, e)
+					}
+				}
+			}
+		}
+		switch len(match) {
+		case 0:
+			level = next
+		case 1:
+			return match[0]
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// taggedField returns the type of the field with name in the struct tag key,
+// with nil for none. Embedded structs are searched too. The lookup is lenient
+// in that it ignores the dominance rules.
+func taggedField(s *types.Struct, key, name string) types.Type {
+	for i := 0; i < s.NumFields(); i++ {
+		f := s.Field(i)
+		tag := reflectTag(s.Tag(i), key)
+		if tag == "-" {
+			continue
+		}
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			tag = tag[:j]
+		}
+		if tag == name || tag == "" && f.Name() == name && f.Exported() {
+			return f.Type()
+		}
+		if tag == "" && f.Embedded() {
+			if e, ok := deref(f.Type()).Underlying().(*types.Struct); ok {
+				if t := taggedField(e, key, name); t != nil {
+					return t
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// reflectTag returns the value of key in tag, conform reflect.StructTag.
+func reflectTag(tag, key string) string {
+	for tag != "" {
+		i := strings.IndexByte(tag, ':')
+		if i < 0 || i+1 >= len(tag) || tag[i+1] != '"' {
+			break
+		}
+		name := strings.TrimLeft(tag[:i], " ")
+		tag = tag[i+1:]
+
+		j := 1
+		for j < len(tag) && tag[j] != '"' {
+			if tag[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:j+1])
+		if err != nil {
+			break
+		}
+		if name == key {
+			return value
+		}
+		tag = tag[j+1:]
+	}
+	return ""
+}
+
+// selectKey returns the type matching key on t, with nil for none, in which
+// case a reason may be given.
+func selectKey(t types.Type, key string) (types.Type, string) {
+	switch u := t.Underlying().(type) {
+	case *types.Map:
+		if key == "*" || key[0] == '?' || fitsKey(key, u.Key()) {
+			return u.Elem(), ""
+		}
+		return nil, "map key type " + u.Key().String() + " does not take " + key
+
+	case *types.Array:
+		if i, err := strconv.ParseUint(key, 0, 64); err == nil && i >= uint64(u.Len()) {
+			return nil, "index " + key + " out of bounds for " + t.String()
+		}
+		if isElementKey(key) {
+			return u.Elem(), ""
+		}
+
+	case *types.Slice:
+		if isElementKey(key) {
+			return u.Elem(), ""
+		}
+
+	case *types.Basic:
+		if u.Info()&types.IsString != 0 && isElementKey(key) {
+			return types.Typ[types.Byte], ""
+		}
+
+	}
+	return nil, "type " + t.String() + " does not take key " + key
+}
+
+// isElementKey returns whether key can select elements from indexed types.
+func isElementKey(key string) bool {
+	if key == "*" || key[0] == '?' {
+		return true
+	}
+	for i := 0; i < len(key); i++ {
+		if !strings.ContainsRune("0123456789abcdefABCDEFoOxX_+-:", rune(key[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// fitsKey returns whether literal applies to map key type t, conform the
+// interpretation of key selections in package el.
+func fitsKey(literal string, t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return true // fmt.Sscan; unknown
+	}
+	info := b.Info()
+	switch {
+	case info&types.IsString != 0:
+		_, err := strconv.Unquote(literal)
+		return err == nil && literal[0] != '\''
+	case info&types.IsInteger != 0:
+		if literal[0] == '\'' {
+			_, err := strconv.Unquote(literal)
+			return err == nil
+		}
+		if info&types.IsUnsigned != 0 {
+			_, err := strconv.ParseUint(literal, 0, 64)
+			return err == nil
+		}
+		_, err := strconv.ParseInt(literal, 0, 64)
+		return err == nil
+	case info&types.IsFloat != 0:
+		_, err := strconv.ParseFloat(literal, 64)
+		return err == nil
+	}
+	return true
+}
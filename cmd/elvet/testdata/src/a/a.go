@@ -0,0 +1,110 @@
+package a
+
+import (
+	"fmt"
+
+	"github.com/pascaldekloe/goe/el"
+	"github.com/pascaldekloe/goe/rest"
+)
+
+type Cache struct {
+	TTL    int               `json:"cache_ttl"`
+	Labels map[string]string `json:"labels"`
+	ByID   map[int]*Node
+	Slots  [4]uint8
+	Names  []string
+	Meta
+}
+
+type Meta struct {
+	Owner string `json:"owner"`
+}
+
+type Node struct {
+	Name  string
+	Cache *Cache
+	Any   interface{}
+	next  *Node
+}
+
+func (c *Cache) Size() int { return len(c.Names) }
+
+func (c Cache) Label(key string) (string, error) { return c.Labels[key], nil }
+
+func (c *Cache) Reset() error { return nil }
+
+const ttlPath = "/Cache/TTL"
+
+func lookups(n *Node, root interface{}) {
+	el.Int(ttlPath, n)
+	el.Int("/Cahce/TTL", n)    // want `GoEL "/Cahce/TTL": type a.Node has no field Cahce`
+	el.String("/Cache/TTL", n) // want `GoEL "/Cache/TTL": el.String does not apply to result type int`
+	el.Uint("/Cache/Slots[3]", n)
+	el.Uint("/Cache/Slots[4]", n) // want `index 4 out of bounds for \[4\]uint8`
+	el.String(`/Cache/Labels["x"]`, n)
+	el.String(`/Cache/Labels[7]`, n) // want `map key type string does not take 7`
+	el.String(`/Cache/ByID[7]/Name`, n)
+	el.String(`/Cache/ByID["7"]/Name`, n) // want `map key type int does not take "7"`
+	el.String("/Cache/Owner", n)
+	el.String("/Cache/@json:owner", n)
+	el.Int("/Cache/@json:TTL", n) // want `type a.Cache has no field with json name "TTL"`
+	el.Strings("/Cache/Names[*]", n)
+	el.Strings("/Cache/Names[?. == \"x\"]", n)
+	el.Strings("/Cache/Meta/*", n)
+	el.Bool("/Cache/*", n) // want `el.Bool does not apply to result type`
+	el.Int("/Any/Whatever", n)
+	el.Int("/**/Whatever", n)
+	el.Int("/next/next/Cache/TTL", n)
+	el.Int("/X", root)
+	el.Any("/Name[", n) // want `goe el: expression "/Name\[" has unterminated key at offset 5`
+	el.Strings("/.[*]/Name", []Node{})
+	el.MustCompile("/Name]")
+	el.MustCompile("/[0]")      // want `key without selection`
+	el.MustCompile("/X").Int(n) // want `GoEL "/X": type a.Node has no field X`
+	el.MustCompile("/Cache/TTL").Int(n)
+	el.MustCompile("/Cache/TTL").String(n) // want `el.String does not apply to result type int`
+	el.MustCompile("/[0]").Int(n)          // want `key without selection`
+	el.MustCompile("/Mis").IsPath()
+	el.Get[int]("/Cache/TTL", n)
+	el.Bool("len(/Cache/Names) > 2 && /Cache/Absent", n)
+	el.Bool("len(/Cache/Names", n)          // want `goe el: expression "len\(/Cache/Names" offset 16: closing parenthesis of len missing`
+	el.GetAll[string]("/Cache/Nmaes[*]", n) // want `type a.Cache has no field Nmaes`
+	el.Int("/Cache/Size()", n)
+	el.String(`/Cache/Label("a,b")`, n)
+	el.String("/Cache/Size()", n)  // want `el.String does not apply to result type int`
+	el.Int("/Cache/Sise()", n)     // want `type a.Cache has no method Sise`
+	el.String("/Cache/Label()", n) // want `method Label of type a.Cache takes 1 arguments, not 0`
+	el.Bool("/Cache/Size() > 2", n)
+	el.Any("/Cache/Reset()", n) // want `method Reset of type a.Cache does not return one value, other than an error`
+}
+
+func modifications(n *Node, paths []string) {
+	el.Assign(n, "/Cache/TTL", 42)
+	el.Assign(n, "/Cache/TTL", int8(42))
+	el.Assign(n, "/Cache/Names", []string{})
+	el.Assign(n, "/Cache/Names", "x") // want `GoEL "/Cache/Names": value does not apply to type \[\]string`
+	el.Assign(n, "/Cache/Labels", nil)
+	el.Delete(n, "/Cache/Mis")                                  // want `type a.Cache has no field Mis`
+	el.Delete(n, "true ? /Cache : /Any")                        // want `modification applies to paths only`
+	el.Redact(n, "/Cache/Labels[*]", "/Cache/Owner", "/Name/X") // want `type string has no fields`
+	el.RedactWith(*n, "***", "/Cache/Nmaes")                    // want `type a.Cache has no field Nmaes`
+	el.Redact(n, "/Name", "len(/Name)")                         // want `modification applies to paths only`
+	el.Redact(n, paths...)
+	el.MustCompile("/Cache/Names").Assign(n, "x") // want `GoEL "/Cache/Names": value does not apply to type \[\]string`
+	(el.MustCompile("/Cache/Mis")).Delete(n)      // want `type a.Cache has no field Mis`
+}
+
+func projections(n *Node) {
+	el.Project(n, "/Name", "/Cache/Labels[*]")
+	el.ProjectMap(*n, "/Cache/Nmaes")    // want `type a.Cache has no field Nmaes`
+	el.Project(n, "/Name", "len(/Name)") // want `projection applies to paths only`
+}
+
+func unrelated() string {
+	return fmt.Sprintf("%s", "/Mis")
+}
+
+func repos() {
+	rest.NewCRUD("/nodes", "/Version")
+	rest.NewCRUD("/nodes", "Version") // want `path "Version" is not absolute`
+}
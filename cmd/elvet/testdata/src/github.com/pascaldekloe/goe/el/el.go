@@ -0,0 +1,27 @@
+// Package el is a stub for the analysis tests.
+package el
+
+func Bool(expr string, root interface{}) (bool, bool)                { return false, false }
+func Int(expr string, root interface{}) (int64, bool)                { return 0, false }
+func Uint(expr string, root interface{}) (uint64, bool)              { return 0, false }
+func String(expr string, root interface{}) (string, bool)            { return "", false }
+func Strings(expr string, root interface{}) []string                 { return nil }
+func Any(expr string, root interface{}) []interface{}                { return nil }
+func Assign(root interface{}, path string, want interface{}) int     { return 0 }
+func Delete(root interface{}, path string) int                       { return 0 }
+func Redact(root interface{}, paths ...string) interface{}           { return nil }
+func RedactWith(root, mask interface{}, paths ...string) interface{} { return nil }
+func Project(root interface{}, paths ...string) interface{}          { return nil }
+func ProjectMap(root interface{}, paths ...string) interface{}       { return nil }
+func MustCompile(expr string) *Expr                                  { return nil }
+
+type Expr struct{}
+
+func (x *Expr) Int(root interface{}) (int64, bool)            { return 0, false }
+func (x *Expr) String(root interface{}) (string, bool)        { return "", false }
+func (x *Expr) Assign(root interface{}, want interface{}) int { return 0 }
+func (x *Expr) Delete(root interface{}) int                   { return 0 }
+func (x *Expr) IsPath() bool                                  { return false }
+
+func Get[T any](expr string, root interface{}) (result T, ok bool) { return }
+func GetAll[T any](expr string, root interface{}) []T              { return nil }
@@ -0,0 +1,16 @@
+module github.com/pascaldekloe/goe/cmd
+
+go 1.22.0
+
+require (
+	github.com/pascaldekloe/goe v0.0.0
+	golang.org/x/tools v0.26.0
+)
+
+require (
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+)
+
+// The tools build against the library at the same commit.
+replace github.com/pascaldekloe/goe => ../
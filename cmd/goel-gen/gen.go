@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/pascaldekloe/goe/el"
+)
+
+// spec is an accessor definition.
+type spec struct {
+	pos      string // file and line number
+	typeName string
+	expr     string
+	name     string
+}
+
+// parseSpecs reads the definitions from r, which is named file.
+func parseSpecs(r io.Reader, file string) ([]spec, error) {
+	var specs []spec
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		pos := fmt.Sprintf("%s:%d", file, lineNo)
+
+		fields := splitSpec(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("%s: want a type name, an expression and an optional function name", pos)
+		}
+		s := spec{pos: pos, typeName: fields[0], expr: fields[1]}
+		if len(fields) == 3 {
+			s.name = fields[2]
+		} else {
+			s.name = fields[0] + exprName(fields[1])
+		}
+		if !token.IsIdentifier(s.name) {
+			return nil, fmt.Errorf("%s: function name %q is not an identifier", pos, s.name)
+		}
+		specs = append(specs, s)
+	}
+	return specs, scanner.Err()
+}
+
+// splitSpec returns the fields of line, separated by white space. Quoted
+// literals may contain white space.
+func splitSpec(line string) []string {
+	var fields []string
+	start := -1
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if quote != 0 {
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		if c == ' ' || c == '\t' {
+			if start >= 0 {
+				fields = append(fields, line[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		if c == '"' || c == '\'' || c == '`' {
+			quote = c
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, line[start:])
+	}
+	return fields
+}
+
+// exprName returns an identifier for expr in camel case.
+func exprName(expr string) string {
+	var buf strings.Builder
+	upper := true
+	for _, r := range expr {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// step is a resolved path component.
+type step struct {
+	// fields has the selection, with any embedded fields for promotion.
+	fields []*types.Var
+	// index is the element number, with -1 for none.
+	index int
+	// key is the map key in Go notation, with "" for none.
+	key string
+	// typ is the type selected.
+	typ types.Type
+}
+
+// generator writes Go source for a package.
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string // name per path
+}
+
+// load returns the package in dir.
+func load(dir string) (*types.Package, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 || pkgs[0].Types == nil {
+		return nil, fmt.Errorf("no package in %s", dir)
+	}
+	// Type errors are ignored, as the output of a previous run may be
+	// outdated.
+	return pkgs[0].Types, nil
+}
+
+// generate returns the accessors of specs for pkg.
+func generate(pkg *types.Package, specs []spec) ([]byte, error) {
+	g := &generator{pkg: pkg, imports: make(map[string]string)}
+	var body bytes.Buffer
+	for _, s := range specs {
+		obj, ok := pkg.Scope().Lookup(s.typeName).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("%s: no type %s in package %s", s.pos, s.typeName, pkg.Path())
+		}
+		steps, err := g.resolve(obj.Type(), s.expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.pos, err)
+		}
+		g.writeGetter(&body, s, obj.Type(), steps)
+		g.writeSetter(&body, s, obj.Type(), steps)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by goel-gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg.Name())
+	if len(g.imports) != 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		buf.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		}
+		buf.WriteString(")\n\n")
+	}
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+// resolve returns the steps of expr on root.
+func (g *generator) resolve(root types.Type, expr string) ([]step, error) {
+	x, err := el.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if !x.IsPath() {
+		return nil, fmt.Errorf("expression %q is not a path", expr)
+	}
+
+	var steps []step
+	t := root
+	for _, c := range x.Segments() {
+		if c.Selection != "" {
+			if c.Selection == "*" || c.Selection == "**" || c.Selection[0] == '@' {
+				return nil, fmt.Errorf("expression %q: selection %s not supported", expr, c.Selection)
+			}
+			if strings.HasSuffix(c.Selection, ")") {
+				return nil, fmt.Errorf("expression %q: method invocation %s not supported", expr, c.Selection)
+			}
+			st, err := g.resolveField(deref(t), c.Selection)
+			if err != nil {
+				return nil, fmt.Errorf("expression %q: %w", expr, err)
+			}
+			steps = append(steps, st)
+			t = st.typ
+		}
+
+		if c.Key != "" {
+			st := step{index: -1}
+			switch u := deref(t).Underlying().(type) {
+			case *types.Slice, *types.Array:
+				i, err := strconv.ParseUint(c.Key, 0, 31)
+				if err != nil {
+					return nil, fmt.Errorf("expression %q: key [%s] not supported on %s", expr, c.Key, deref(t))
+				}
+				if a, ok := u.(*types.Array); ok && int64(i) >= a.Len() {
+					return nil, fmt.Errorf("expression %q: index %d out of bounds for %s", expr, i, deref(t))
+				}
+				st.index = int(i)
+				st.typ = u.(interface{ Elem() types.Type }).Elem()
+
+			case *types.Map:
+				lit, err := keyLiteral(c.Key, u.Key())
+				if err != nil {
+					return nil, fmt.Errorf("expression %q: key [%s]: %w", expr, c.Key, err)
+				}
+				st.key = lit
+				st.typ = u.Elem()
+
+			default:
+				return nil, fmt.Errorf("expression %q: type %s has no keys", expr, deref(t))
+			}
+			steps = append(steps, st)
+			t = st.typ
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("expression %q selects the root", expr)
+	}
+	return steps, nil
+}
+
+// resolveField returns the step for the field with name in t.
+func (g *generator) resolveField(t types.Type, name string) (step, error) {
+	s, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return step{}, fmt.Errorf("type %s has no fields", t)
+	}
+	obj, index, _ := types.LookupFieldOrMethod(t, false, g.pkg, name)
+	if f, ok := obj.(*types.Var); !ok || !f.IsField() {
+		return step{}, fmt.Errorf("type %s has no field %s", t, name)
+	}
+
+	st := step{index: -1}
+	for i, x := range index {
+		f := s.Field(x)
+		if !f.Exported() {
+			// conform package el
+			return step{}, fmt.Errorf("field %s of type %s is not exported", f.Name(), t)
+		}
+		st.fields = append(st.fields, f)
+		st.typ = f.Type()
+		if i < len(index)-1 {
+			s = deref(f.Type()).Underlying().(*types.Struct)
+		}
+	}
+	return st, nil
+}
+
+// keyLiteral returns the Go notation of key selection literal for map key
+// type t, conform the interpretation of package el.
+func keyLiteral(literal string, t types.Type) (string, error) {
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", fmt.Errorf("map key type %s not supported", t)
+	}
+
+	info := b.Info()
+	switch {
+	case info&types.IsString != 0:
+		if s, err := strconv.Unquote(literal); err == nil {
+			return strconv.Quote(s), nil
+		}
+
+	case info&types.IsInteger != 0:
+		bits := int(types.SizesFor("gc", "amd64").Sizeof(b)) * 8
+		if literal[0] == '\'' {
+			s, err := strconv.Unquote(literal)
+			if err != nil || len([]rune(s)) != 1 {
+				break
+			}
+			literal = strconv.Itoa(int([]rune(s)[0]))
+		}
+		if info&types.IsUnsigned != 0 {
+			u, err := strconv.ParseUint(literal, 0, bits)
+			if err == nil {
+				return strconv.FormatUint(u, 10), nil
+			}
+		} else {
+			i, err := strconv.ParseInt(literal, 0, bits)
+			if err == nil {
+				return strconv.FormatInt(i, 10), nil
+			}
+		}
+
+	case info&types.IsFloat != 0:
+		f, err := strconv.ParseFloat(literal, 64)
+		if err == nil {
+			return strconv.FormatFloat(f, 'g', -1, 64), nil
+		}
+
+	default:
+		return "", fmt.Errorf("map key type %s not supported", t)
+	}
+	return "", errors.New("literal does not fit map key type " + t.String())
+}
+
+// typeString returns the Go notation of t, with any imports registered.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+// writeGetter writes the lookup function of s.
+func (g *generator) writeGetter(w *bytes.Buffer, s spec, root types.Type, steps []step) {
+	leaf := deref(steps[len(steps)-1].typ)
+	fmt.Fprintf(w, "// Get%s returns the value at %s on root, if any.\n", s.name, s.expr)
+	fmt.Fprintf(w, "func Get%s(root *%s) (v %s, ok bool) {\n", s.name, g.typeString(root), g.typeString(leaf))
+
+	var vars int
+	deref := func(cur string, t types.Type) (string, types.Type) {
+		for {
+			p, ok := t.Underlying().(*types.Pointer)
+			if !ok {
+				return cur, t
+			}
+			fmt.Fprintf(w, "if %s == nil {\nreturn\n}\n", bare(cur))
+			cur, t = "(*"+bare(cur)+")", p.Elem()
+		}
+	}
+
+	cur, t := "root", types.Type(types.NewPointer(root))
+	for _, st := range steps {
+		for _, f := range st.fields {
+			cur, t = deref(cur, t)
+			cur, t = selector(cur, f.Name()), f.Type()
+		}
+		if st.fields != nil {
+			continue
+		}
+
+		cur, t = deref(cur, t)
+		switch t.Underlying().(type) {
+		case *types.Slice:
+			fmt.Fprintf(w, "if len(%s) <= %d {\nreturn\n}\n", bare(cur), st.index)
+			cur = fmt.Sprintf("%s[%d]", cur, st.index)
+		case *types.Array:
+			cur = fmt.Sprintf("%s[%d]", cur, st.index)
+		case *types.Map:
+			vars++
+			fmt.Fprintf(w, "x%d, ok%d := %s[%s]\nif !ok%d {\nreturn\n}\n", vars, vars, cur, st.key, vars)
+			cur = fmt.Sprintf("x%d", vars)
+		}
+		t = st.typ
+	}
+	cur, _ = deref(cur, t)
+	fmt.Fprintf(w, "return %s, true\n}\n\n", bare(cur))
+}
+
+// writeSetter writes the assignment function of s.
+func (g *generator) writeSetter(w *bytes.Buffer, s spec, root types.Type, steps []step) {
+	leaf := deref(steps[len(steps)-1].typ)
+	fmt.Fprintf(w, "// Set%s assigns v at %s on root.\n", s.name, s.expr)
+	fmt.Fprintf(w, "// Absent content in the path is instantiated on the fly.\n")
+	fmt.Fprintf(w, "// The return is false for a nil root only.\n")
+	fmt.Fprintf(w, "func Set%s(root *%s, v %s) bool {\n", s.name, g.typeString(root), g.typeString(leaf))
+	fmt.Fprintf(w, "if root == nil {\nreturn false\n}\n")
+
+	deref := func(cur string, t types.Type) (string, types.Type) {
+		for {
+			p, ok := t.Underlying().(*types.Pointer)
+			if !ok {
+				return cur, t
+			}
+			fmt.Fprintf(w, "if %s == nil {\n%s = new(%s)\n}\n", bare(cur), bare(cur), g.typeString(p.Elem()))
+			cur, t = "(*"+bare(cur)+")", p.Elem()
+		}
+	}
+
+	var vars int
+	var writeBacks []string // in reverse order
+	cur, t := "(*root)", root
+	for i, st := range steps {
+		for _, f := range st.fields {
+			cur, t = deref(cur, t)
+			cur, t = selector(cur, f.Name()), f.Type()
+		}
+		if st.fields != nil {
+			continue
+		}
+
+		cur, t = deref(cur, t)
+		switch t.Underlying().(type) {
+		case *types.Slice:
+			fmt.Fprintf(w, "if n := %d - len(%s); n >= 0 {\n%s = append(%s, make(%s, n+1)...)\n}\n", st.index, bare(cur), bare(cur), bare(cur), g.typeString(t))
+			cur = fmt.Sprintf("%s[%d]", cur, st.index)
+		case *types.Array:
+			cur = fmt.Sprintf("%s[%d]", cur, st.index)
+		case *types.Map:
+			fmt.Fprintf(w, "if %s == nil {\n%s = make(%s)\n}\n", bare(cur), bare(cur), g.typeString(t))
+			entry := fmt.Sprintf("%s[%s]", cur, st.key)
+			if i == len(steps)-1 {
+				cur = entry
+				break
+			}
+			// map values are not addressable
+			vars++
+			fmt.Fprintf(w, "e%d := %s\n", vars, entry)
+			writeBacks = append(writeBacks, fmt.Sprintf("%s = e%d\n", entry, vars))
+			cur = fmt.Sprintf("e%d", vars)
+		}
+		t = st.typ
+	}
+	cur, _ = deref(cur, t)
+	fmt.Fprintf(w, "%s = v\n", bare(cur))
+	for i := len(writeBacks) - 1; i >= 0; i-- {
+		w.WriteString(writeBacks[i])
+	}
+	w.WriteString("return true\n}\n\n")
+}
+
+// selector returns the field selection of name on cur. Pointer dereference is
+// omitted where possible.
+func selector(cur, name string) string {
+	if x := bare(cur); x != cur && strings.HasPrefix(x, "*") && !strings.HasPrefix(x, "**") {
+		return x[1:] + "." + name
+	}
+	return cur + "." + name
+}
+
+// bare returns x without any enclosing parenthesis.
+func bare(x string) string {
+	if !strings.HasPrefix(x, "(") || !strings.HasSuffix(x, ")") {
+		return x
+	}
+	depth := 0
+	for i := 0; i < len(x)-1; i++ {
+		switch x[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return x // closed before the end
+			}
+		}
+	}
+	return x[1 : len(x)-1]
+}
+
+// deref returns the element type of pointers, recursively.
+func deref(t types.Type) types.Type {
+	for {
+		p, ok := t.Underlying().(*types.Pointer)
+		if !ok {
+			return t
+		}
+		t = p.Elem()
+	}
+}
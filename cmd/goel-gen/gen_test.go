@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+// TestGolden verifies that the generated source of the sample is current.
+func TestGolden(t *testing.T) {
+	dir := filepath.Join("internal", "sample")
+	f, err := os.Open(filepath.Join(dir, "goel.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	specs, err := parseSpecs(f, "goel.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate(pkg, specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, "goel_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("generated source differs from internal/sample/goel_gen.go; run go generate")
+	}
+}
+
+func TestParseSpecs(t *testing.T) {
+	specs, err := parseSpecs(strings.NewReader(`
+# comment
+Node /Name
+	Node   /Labels["a b"]   Spaced
+Node /Ports['\''][0]
+`), "spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []spec{
+		{pos: "spec:3", typeName: "Node", expr: "/Name", name: "NodeName"},
+		{pos: "spec:4", typeName: "Node", expr: `/Labels["a b"]`, name: "Spaced"},
+		{pos: "spec:5", typeName: "Node", expr: `/Ports['\''][0]`, name: "NodePorts0"},
+	}
+	verify.Values(t, "specs", specs, want)
+
+	for _, src := range []string{"Node", "Node /Name A B", "Node /Name 2nd"} {
+		if _, err := parseSpecs(strings.NewReader(src), "spec"); err == nil {
+			t.Errorf("%q: no error", src)
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	tests := []struct{ spec, err string }{
+		{"Absent /Name", "spec:1: no type Absent in package github.com/pascaldekloe/goe/cmd/goel-gen/internal/sample"},
+		{"Node /Kids/..", `spec:1: expression "/Kids/.." selects the root`},
+		{"Node /*", `spec:1: expression "/*": selection * not supported`},
+		{"Node /**/Name", `spec:1: expression "/**/Name": selection ** not supported`},
+		{"Node /@json:name", `spec:1: expression "/@json:name": selection @json:name not supported`},
+		{"Node /Kids[*]", `spec:1: expression "/Kids[*]": key [*] not supported on []*github.com/pascaldekloe/goe/cmd/goel-gen/internal/sample.Node`},
+		{"Node /Kids[1:2]", `spec:1: expression "/Kids[1:2]": key [1:2] not supported on []*github.com/pascaldekloe/goe/cmd/goel-gen/internal/sample.Node`},
+		{"Node /Addr[4]", `spec:1: expression "/Addr[4]": index 4 out of bounds for [4]uint8`},
+		{"Node /Labels[1]", `spec:1: expression "/Labels[1]": key [1]: literal does not fit map key type string`},
+		{"Node /Ports[?Proto]", `spec:1: expression "/Ports[?Proto]": key [?Proto]: literal does not fit map key type int64`},
+		{"Node /Absent", `spec:1: expression "/Absent": type github.com/pascaldekloe/goe/cmd/goel-gen/internal/sample.Node has no field Absent`},
+		{"Node /Name/Length", `spec:1: expression "/Name/Length": type string has no fields`},
+		{"Node /Name/Len()", `spec:1: expression "/Name/Len()": method invocation Len() not supported`},
+		{"Node /Name[0]", `spec:1: expression "/Name[0]": type string has no keys`},
+	}
+	pkg, err := load(filepath.Join("internal", "sample"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		specs, err := parseSpecs(strings.NewReader(test.spec), "spec")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = generate(pkg, specs)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: got error %v, want %s", test.spec, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+// Code generated by goel-gen; DO NOT EDIT.
+
+package sample
+
+import (
+	"time"
+)
+
+// GetNodeName returns the value at /Name on root, if any.
+func GetNodeName(root *Node) (v string, ok bool) {
+	if root == nil {
+		return
+	}
+	return root.Name, true
+}
+
+// SetNodeName assigns v at /Name on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetNodeName(root *Node, v string) bool {
+	if root == nil {
+		return false
+	}
+	root.Name = v
+	return true
+}
+
+// GetNodeCacheTTL returns the value at /Cache/TTL on root, if any.
+func GetNodeCacheTTL(root *Node) (v time.Duration, ok bool) {
+	if root == nil {
+		return
+	}
+	if root.Cache == nil {
+		return
+	}
+	return root.Cache.TTL, true
+}
+
+// SetNodeCacheTTL assigns v at /Cache/TTL on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetNodeCacheTTL(root *Node, v time.Duration) bool {
+	if root == nil {
+		return false
+	}
+	if root.Cache == nil {
+		root.Cache = new(Cache)
+	}
+	root.Cache.TTL = v
+	return true
+}
+
+// GetCacheSize returns the value at /Cache/Size on root, if any.
+func GetCacheSize(root *Node) (v uint32, ok bool) {
+	if root == nil {
+		return
+	}
+	if root.Cache == nil {
+		return
+	}
+	return root.Cache.Size, true
+}
+
+// SetCacheSize assigns v at /Cache/Size on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetCacheSize(root *Node, v uint32) bool {
+	if root == nil {
+		return false
+	}
+	if root.Cache == nil {
+		root.Cache = new(Cache)
+	}
+	root.Cache.Size = v
+	return true
+}
+
+// GetNodeEnv returns the value at /Labels["env"] on root, if any.
+func GetNodeEnv(root *Node) (v string, ok bool) {
+	if root == nil {
+		return
+	}
+	x1, ok1 := root.Labels["env"]
+	if !ok1 {
+		return
+	}
+	return x1, true
+}
+
+// SetNodeEnv assigns v at /Labels["env"] on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetNodeEnv(root *Node, v string) bool {
+	if root == nil {
+		return false
+	}
+	if root.Labels == nil {
+		root.Labels = make(map[string]string)
+	}
+	root.Labels["env"] = v
+	return true
+}
+
+// GetKidName returns the value at /Kids[2]/Name on root, if any.
+func GetKidName(root *Node) (v string, ok bool) {
+	if root == nil {
+		return
+	}
+	if len(root.Kids) <= 2 {
+		return
+	}
+	if root.Kids[2] == nil {
+		return
+	}
+	return root.Kids[2].Name, true
+}
+
+// SetKidName assigns v at /Kids[2]/Name on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetKidName(root *Node, v string) bool {
+	if root == nil {
+		return false
+	}
+	if n := 2 - len(root.Kids); n >= 0 {
+		root.Kids = append(root.Kids, make([]*Node, n+1)...)
+	}
+	if root.Kids[2] == nil {
+		root.Kids[2] = new(Node)
+	}
+	root.Kids[2].Name = v
+	return true
+}
+
+// GetNodeKids1CacheTTL returns the value at /Kids[1]/Cache/TTL on root, if any.
+func GetNodeKids1CacheTTL(root *Node) (v time.Duration, ok bool) {
+	if root == nil {
+		return
+	}
+	if len(root.Kids) <= 1 {
+		return
+	}
+	if root.Kids[1] == nil {
+		return
+	}
+	if root.Kids[1].Cache == nil {
+		return
+	}
+	return root.Kids[1].Cache.TTL, true
+}
+
+// SetNodeKids1CacheTTL assigns v at /Kids[1]/Cache/TTL on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetNodeKids1CacheTTL(root *Node, v time.Duration) bool {
+	if root == nil {
+		return false
+	}
+	if n := 1 - len(root.Kids); n >= 0 {
+		root.Kids = append(root.Kids, make([]*Node, n+1)...)
+	}
+	if root.Kids[1] == nil {
+		root.Kids[1] = new(Node)
+	}
+	if root.Kids[1].Cache == nil {
+		root.Kids[1].Cache = new(Cache)
+	}
+	root.Kids[1].Cache.TTL = v
+	return true
+}
+
+// GetNodeKids0LabelsX returns the value at /Kids[0]/Labels['x'] on root, if any.
+func GetNodeKids0LabelsX(root *Node) (v string, ok bool) {
+	if root == nil {
+		return
+	}
+	if len(root.Kids) <= 0 {
+		return
+	}
+	if root.Kids[0] == nil {
+		return
+	}
+	x1, ok1 := root.Kids[0].Labels["x"]
+	if !ok1 {
+		return
+	}
+	return x1, true
+}
+
+// SetNodeKids0LabelsX assigns v at /Kids[0]/Labels['x'] on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetNodeKids0LabelsX(root *Node, v string) bool {
+	if root == nil {
+		return false
+	}
+	if n := 0 - len(root.Kids); n >= 0 {
+		root.Kids = append(root.Kids, make([]*Node, n+1)...)
+	}
+	if root.Kids[0] == nil {
+		root.Kids[0] = new(Node)
+	}
+	if root.Kids[0].Labels == nil {
+		root.Kids[0].Labels = make(map[string]string)
+	}
+	root.Kids[0].Labels["x"] = v
+	return true
+}
+
+// GetNodePorts8080Proto returns the value at /Ports[8080]/Proto on root, if any.
+func GetNodePorts8080Proto(root *Node) (v string, ok bool) {
+	if root == nil {
+		return
+	}
+	x1, ok1 := root.Ports[8080]
+	if !ok1 {
+		return
+	}
+	return x1.Proto, true
+}
+
+// SetNodePorts8080Proto assigns v at /Ports[8080]/Proto on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetNodePorts8080Proto(root *Node, v string) bool {
+	if root == nil {
+		return false
+	}
+	if root.Ports == nil {
+		root.Ports = make(map[int64]Port)
+	}
+	e1 := root.Ports[8080]
+	e1.Proto = v
+	root.Ports[8080] = e1
+	return true
+}
+
+// GetHTTPSTag returns the value at /Ports[0x1bb]/Tags[1] on root, if any.
+func GetHTTPSTag(root *Node) (v string, ok bool) {
+	if root == nil {
+		return
+	}
+	x1, ok1 := root.Ports[443]
+	if !ok1 {
+		return
+	}
+	if len(x1.Tags) <= 1 {
+		return
+	}
+	return x1.Tags[1], true
+}
+
+// SetHTTPSTag assigns v at /Ports[0x1bb]/Tags[1] on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetHTTPSTag(root *Node, v string) bool {
+	if root == nil {
+		return false
+	}
+	if root.Ports == nil {
+		root.Ports = make(map[int64]Port)
+	}
+	e1 := root.Ports[443]
+	if n := 1 - len(e1.Tags); n >= 0 {
+		e1.Tags = append(e1.Tags, make([]string, n+1)...)
+	}
+	e1.Tags[1] = v
+	root.Ports[443] = e1
+	return true
+}
+
+// GetNodeAddr3 returns the value at /Addr[3] on root, if any.
+func GetNodeAddr3(root *Node) (v uint8, ok bool) {
+	if root == nil {
+		return
+	}
+	return root.Addr[3], true
+}
+
+// SetNodeAddr3 assigns v at /Addr[3] on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetNodeAddr3(root *Node, v uint8) bool {
+	if root == nil {
+		return false
+	}
+	root.Addr[3] = v
+	return true
+}
+
+// GetNodeWeight returns the value at /Weight on root, if any.
+func GetNodeWeight(root *Node) (v int, ok bool) {
+	if root == nil {
+		return
+	}
+	if root.Weight == nil {
+		return
+	}
+	if *root.Weight == nil {
+		return
+	}
+	return **root.Weight, true
+}
+
+// SetNodeWeight assigns v at /Weight on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetNodeWeight(root *Node, v int) bool {
+	if root == nil {
+		return false
+	}
+	if root.Weight == nil {
+		root.Weight = new(*int)
+	}
+	if *root.Weight == nil {
+		*root.Weight = new(int)
+	}
+	**root.Weight = v
+	return true
+}
+
+// GetNodeOwner returns the value at /Owner on root, if any.
+func GetNodeOwner(root *Node) (v string, ok bool) {
+	if root == nil {
+		return
+	}
+	if root.Meta == nil {
+		return
+	}
+	return root.Meta.Owner, true
+}
+
+// SetNodeOwner assigns v at /Owner on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetNodeOwner(root *Node, v string) bool {
+	if root == nil {
+		return false
+	}
+	if root.Meta == nil {
+		root.Meta = new(Meta)
+	}
+	root.Meta.Owner = v
+	return true
+}
+
+// GetNodeMetaCreated returns the value at /Meta/Created on root, if any.
+func GetNodeMetaCreated(root *Node) (v time.Time, ok bool) {
+	if root == nil {
+		return
+	}
+	if root.Meta == nil {
+		return
+	}
+	return root.Meta.Created, true
+}
+
+// SetNodeMetaCreated assigns v at /Meta/Created on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetNodeMetaCreated(root *Node, v time.Time) bool {
+	if root == nil {
+		return false
+	}
+	if root.Meta == nil {
+		root.Meta = new(Meta)
+	}
+	root.Meta.Created = v
+	return true
+}
+
+// GetSelf returns the value at /Kids/../Name on root, if any.
+func GetSelf(root *Node) (v string, ok bool) {
+	if root == nil {
+		return
+	}
+	return root.Name, true
+}
+
+// SetSelf assigns v at /Kids/../Name on root.
+// Absent content in the path is instantiated on the fly.
+// The return is false for a nil root only.
+func SetSelf(root *Node, v string) bool {
+	if root == nil {
+		return false
+	}
+	root.Name = v
+	return true
+}
@@ -0,0 +1,37 @@
+// Package sample has generated accessors for the verification of goel-gen.
+package sample
+
+import "time"
+
+//go:generate go run github.com/pascaldekloe/goe/cmd/goel-gen -o goel_gen.go goel.txt
+
+// Node is a tree.
+type Node struct {
+	*Meta
+
+	Name   string
+	Cache  *Cache
+	Labels map[string]string
+	Kids   []*Node
+	Ports  map[int64]Port
+	Addr   [4]uint8
+	Weight **int
+}
+
+// Meta is embedded.
+type Meta struct {
+	Created time.Time
+	Owner   string
+}
+
+// Cache has settings.
+type Cache struct {
+	TTL  time.Duration
+	Size uint32
+}
+
+// Port is a map value.
+type Port struct {
+	Proto string
+	Tags  []string
+}
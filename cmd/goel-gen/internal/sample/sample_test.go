@@ -0,0 +1,131 @@
+package sample
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/el"
+	"github.com/pascaldekloe/goe/verify"
+)
+
+// accessors has the generated functions per expression, with a value for
+// the setter.
+var accessors = []struct {
+	expr  string
+	get   func(*Node) (interface{}, bool)
+	set   func(*Node) bool
+	value interface{}
+}{
+	{"/Name",
+		func(n *Node) (interface{}, bool) { return GetNodeName(n) },
+		func(n *Node) bool { return SetNodeName(n, "set") }, "set"},
+	{"/Cache/TTL",
+		func(n *Node) (interface{}, bool) { return GetNodeCacheTTL(n) },
+		func(n *Node) bool { return SetNodeCacheTTL(n, time.Minute) }, time.Minute},
+	{"/Cache/Size",
+		func(n *Node) (interface{}, bool) { return GetCacheSize(n) },
+		func(n *Node) bool { return SetCacheSize(n, 99) }, uint32(99)},
+	{`/Labels["env"]`,
+		func(n *Node) (interface{}, bool) { return GetNodeEnv(n) },
+		func(n *Node) bool { return SetNodeEnv(n, "set") }, "set"},
+	{"/Kids[2]/Name",
+		func(n *Node) (interface{}, bool) { return GetKidName(n) },
+		func(n *Node) bool { return SetKidName(n, "set") }, "set"},
+	{"/Kids[1]/Cache/TTL",
+		func(n *Node) (interface{}, bool) { return GetNodeKids1CacheTTL(n) },
+		func(n *Node) bool { return SetNodeKids1CacheTTL(n, time.Hour) }, time.Hour},
+	{"/Kids[0]/Labels['x']",
+		func(n *Node) (interface{}, bool) { return GetNodeKids0LabelsX(n) },
+		func(n *Node) bool { return SetNodeKids0LabelsX(n, "set") }, "set"},
+	{"/Ports[8080]/Proto",
+		func(n *Node) (interface{}, bool) { return GetNodePorts8080Proto(n) },
+		func(n *Node) bool { return SetNodePorts8080Proto(n, "set") }, "set"},
+	{"/Ports[0x1bb]/Tags[1]",
+		func(n *Node) (interface{}, bool) { return GetHTTPSTag(n) },
+		func(n *Node) bool { return SetHTTPSTag(n, "set") }, "set"},
+	{"/Addr[3]",
+		func(n *Node) (interface{}, bool) { return GetNodeAddr3(n) },
+		func(n *Node) bool { return SetNodeAddr3(n, 7) }, uint8(7)},
+	{"/Weight",
+		func(n *Node) (interface{}, bool) { return GetNodeWeight(n) },
+		func(n *Node) bool { return SetNodeWeight(n, 42) }, 42},
+	{"/Owner",
+		func(n *Node) (interface{}, bool) { return GetNodeOwner(n) },
+		func(n *Node) bool { return SetNodeOwner(n, "set") }, "set"},
+	{"/Meta/Created",
+		func(n *Node) (interface{}, bool) { return GetNodeMetaCreated(n) },
+		func(n *Node) bool { return SetNodeMetaCreated(n, time.Unix(1, 0)) }, time.Unix(1, 0)},
+	{"/Kids/../Name",
+		func(n *Node) (interface{}, bool) { return GetSelf(n) },
+		func(n *Node) bool { return SetSelf(n, "set") }, "set"},
+}
+
+// fixtures returns new instances of each test case.
+func fixtures() []*Node {
+	weight := new(int)
+	*weight = 3
+	return []*Node{
+		nil,
+		{},
+		{
+			Meta:   &Meta{Owner: "o", Created: time.Unix(0, 0)},
+			Name:   "root",
+			Cache:  &Cache{TTL: time.Second, Size: 1},
+			Labels: map[string]string{"env": "prod", "x": "y"},
+			Kids: []*Node{
+				{Labels: map[string]string{"x": "z"}},
+				{Cache: &Cache{TTL: time.Millisecond}},
+				{Name: "third"},
+			},
+			Ports: map[int64]Port{
+				8080: {Proto: "http"},
+				443:  {Proto: "https", Tags: []string{"a", "b", "c"}},
+			},
+			Addr:   [4]uint8{127, 0, 0, 1},
+			Weight: &weight,
+		},
+		{
+			Labels: map[string]string{},
+			Kids:   []*Node{nil},
+			Ports:  map[int64]Port{443: {Tags: []string{"a"}}},
+			Weight: new(*int),
+		},
+	}
+}
+
+// TestGetters verifies the generated lookups against the reflective evaluation.
+func TestGetters(t *testing.T) {
+	for _, a := range accessors {
+		for i, root := range fixtures() {
+			got, ok := a.get(root)
+			want := el.Any(a.expr, root)
+			if ok && len(want) == 1 {
+				// apply the normalization of Any, like int64 for int
+				got = reflect.ValueOf(got).Convert(reflect.TypeOf(want[0])).Interface()
+			}
+			switch {
+			case ok != (len(want) == 1):
+				t.Errorf("%s on fixture %d: got %v, %t; reflective evaluation has %d results", a.expr, i, got, ok, len(want))
+			case ok && !reflect.DeepEqual(got, want[0]):
+				t.Errorf("%s on fixture %d: got %#v, want %#v", a.expr, i, got, want[0])
+			}
+		}
+	}
+}
+
+// TestSetters verifies the generated assignments against the reflective evaluation.
+func TestSetters(t *testing.T) {
+	for _, a := range accessors {
+		roots, want := fixtures(), fixtures()
+		for i := range roots {
+			ok := a.set(roots[i])
+			n := el.Assign(want[i], a.expr, a.value)
+			if ok != (n == 1) {
+				t.Errorf("%s on fixture %d: got %t, reflective assignment has %d", a.expr, i, ok, n)
+			}
+			verify.Values(t, fmt.Sprintf("%s on fixture %d", a.expr, i), roots[i], want[i])
+		}
+	}
+}
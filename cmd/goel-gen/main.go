@@ -0,0 +1,69 @@
+// Command goel-gen generates Go accessors for GoEL expressions. The functions
+// need no reflection, and the compiler verifies their use.
+//
+// Each line of the specification file has a type name from the package in the
+// working directory, followed by a path expression, optionally followed by a
+// function name. Empty lines and lines starting with a hash are ignored.
+//
+//	# type expression [name]
+//	Node /Cache/TTL
+//	Node /Labels["env"] NodeEnv
+//
+// Each entry gets a getter and a setter, like GetNodeEnv and SetNodeEnv. The
+// getter has the semantics of the lookup functions in package el: nil pointers,
+// absent map entries and out of bounds indices have no result. The setter has
+// the semantics of el.Assign: content in the path is instantiated on the fly,
+// including slice growth for indices. Only field selections, indices and map
+// key literals are supported. Wildcards, filters, ranges, tag selections and
+// recursive descents depend on the content, and they are rejected therefore.
+// Method invocations have no setter, and they are rejected too.
+//
+// The intended use is with go generate, as in
+//
+//	//go:generate goel-gen -o goel_gen.go goel.txt
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+var outFlag = flag.String("o", "goel_gen.go", "Write the Go source to `file`.")
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("goel-gen: ")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-o file] spec-file\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	specs, err := parseSpecs(f, flag.Arg(0))
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := load(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(pkg, specs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*outFlag, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
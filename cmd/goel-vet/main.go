@@ -0,0 +1,12 @@
+// Command goel-vet checks GoEL expressions. See package elvet for details.
+//
+//	go vet -vettool=$(which goel-vet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/pascaldekloe/goe/cmd/elvet"
+)
+
+func main() { singlechecker.Main(elvet.Analyzer) }
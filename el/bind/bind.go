@@ -0,0 +1,309 @@
+// Package bind applies environment variables and command-line flags onto a
+// configuration struct with GoEL paths.
+//
+// Fields opt in with an "el" tag, which holds the path of the field from the
+// configuration root. Names derive from the path. With prefix "APP", the tag
+// `el:"/Server/MaxConns"` binds environment variable APP_SERVER_MAX_CONNS and
+// flag -server.max-conns. Content in the path is instantiated on the fly,
+// conform el.Assign. Text parses into the type of the field, conform
+// el.AssignText.
+//
+//	type Config struct {
+//		Server *struct {
+//			Port uint16 `el:"/Server/Port"`
+//		}
+//	}
+//
+//	config := new(Config)
+//	b, err := bind.New(config, "APP")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	b.Env()
+//	if err := b.Flags(flag.CommandLine); err != nil {
+//		log.Fatal(err)
+//	}
+//	flag.Parse()
+//	if err := b.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+package bind
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/pascaldekloe/goe/el"
+)
+
+// Binding links a configuration entry to its sources.
+type Binding struct {
+	// Path is the GoEL expression of the target on the configuration.
+	Path string
+	// Env is the name of the environment variable, with "" for none.
+	Env string
+	// Flag is the name of the command-line flag, with "" for none.
+	Flag string
+}
+
+// KeyError is a setting which could not be applied.
+type KeyError struct {
+	// Key is the source, like "$APP_SERVER_PORT" or "-server.port".
+	Key string
+	// Path is the target.
+	Path string
+	// Value is the text provided.
+	Value string
+}
+
+// Error honors the error interface.
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("goe bind: %s value %q not applicable to %s", e.Key, e.Value, e.Path)
+}
+
+// Errors has each failure in order of appearance.
+type Errors []*KeyError
+
+// Error honors the error interface.
+func (errs Errors) Error() string {
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = e.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Binder applies settings onto a configuration.
+type Binder struct {
+	config   interface{}
+	bindings []Binding
+	errs     Errors
+}
+
+// New returns the bindings for config, which must be a pointer to a struct.
+// Tagged fields go first, with environment variables named after prefix, if
+// any. The explicit bindings follow. Each path must be applicable to the type
+// of config, conform el.Check.
+func New(config interface{}, prefix string, explicit ...Binding) (*Binder, error) {
+	t := reflect.TypeOf(config)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("goe bind: configuration type %v is not a struct pointer", t)
+	}
+
+	b := &Binder{config: config}
+	for _, path := range taggedPaths(nil, t.Elem(), make(map[reflect.Type]bool)) {
+		b.bindings = append(b.bindings, Binding{
+			Path: path,
+			Env:  EnvName(prefix, path),
+			Flag: FlagName(path),
+		})
+	}
+	b.bindings = append(b.bindings, explicit...)
+
+	for _, binding := range b.bindings {
+		if err := el.Check(binding.Path, t); err != nil {
+			return nil, fmt.Errorf("goe bind: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// taggedPaths appends the el tag values in t to dst.
+func taggedPaths(dst []string, t reflect.Type, visiting map[reflect.Type]bool) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visiting[t] {
+		return dst
+	}
+	visiting[t] = true
+	for i, n := 0, t.NumField(); i < n; i++ {
+		f := t.Field(i)
+		if path, ok := f.Tag.Lookup("el"); ok && path != "-" {
+			dst = append(dst, path)
+		}
+		dst = taggedPaths(dst, f.Type, visiting)
+	}
+	delete(visiting, t)
+	return dst
+}
+
+// Bindings returns the bindings in use.
+func (b *Binder) Bindings() []Binding {
+	return b.bindings
+}
+
+// Env applies the environment variables which are set.
+func (b *Binder) Env() {
+	for _, binding := range b.bindings {
+		if binding.Env == "" {
+			continue
+		}
+		if text, ok := os.LookupEnv(binding.Env); ok {
+			b.apply("$"+binding.Env, binding.Path, text)
+		}
+	}
+}
+
+// Flags defines the command-line flags on fs. Values which do not apply are
+// recorded for Err, rather than failing the parse, such that all flags are
+// reported at once. Flags on booleans need no value, like "-debug". Names
+// which are defined already, on fs or by another binding, are an error, in
+// which case no flags are defined.
+func (b *Binder) Flags(fs *flag.FlagSet) error {
+	names := make(map[string]string)
+	for _, binding := range b.bindings {
+		if binding.Flag == "" {
+			continue
+		}
+		if path, ok := names[binding.Flag]; ok {
+			return fmt.Errorf("goe bind: flag -%s of %s redefined for %s", binding.Flag, path, binding.Path)
+		}
+		if fs.Lookup(binding.Flag) != nil {
+			return fmt.Errorf("goe bind: flag -%s of %s defined already", binding.Flag, binding.Path)
+		}
+		names[binding.Flag] = binding.Path
+	}
+
+	for _, binding := range b.bindings {
+		if binding.Flag == "" {
+			continue
+		}
+		v := &flagValue{b: b, binding: binding, isBool: b.isBool(binding.Path)}
+		fs.Var(v, binding.Flag, "sets "+binding.Path)
+	}
+	return nil
+}
+
+// isBool returns whether the targets of path are booleans.
+func (b *Binder) isBool(path string) bool {
+	probe := reflect.New(reflect.TypeOf(b.config).Elem()).Interface()
+	if el.AssignText(probe, path, "true") == 0 {
+		return false
+	}
+	for _, v := range el.Any(path, probe) {
+		if _, ok := v.(bool); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns the settings which could not be applied as Errors, if any.
+func (b *Binder) Err() error {
+	if len(b.errs) == 0 {
+		return nil
+	}
+	return b.errs
+}
+
+// apply assigns text on the path, and it records any failure under key.
+func (b *Binder) apply(key, path, text string) {
+	if el.AssignText(b.config, path, text) == 0 {
+		b.errs = append(b.errs, &KeyError{Key: key, Path: path, Value: text})
+	}
+}
+
+// flagValue is a flag.Value for a binding.
+type flagValue struct {
+	b       *Binder
+	binding Binding
+	isBool  bool
+}
+
+// String honors the flag.Value interface.
+func (v *flagValue) String() string {
+	if v == nil || v.b == nil {
+		return ""
+	}
+	values := el.Any(v.binding.Path, v.b.config)
+	if len(values) != 1 {
+		return ""
+	}
+	return fmt.Sprint(values[0])
+}
+
+// Set honors the flag.Value interface.
+func (v *flagValue) Set(text string) error {
+	v.b.apply("-"+v.binding.Flag, v.binding.Path, text)
+	return nil
+}
+
+// IsBoolFlag honors the optional interface of package flag.
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// EnvName returns the environment variable name for path, which consists of
+// the words in the path in upper case, separated by underscores, with an
+// optional prefix.
+func EnvName(prefix, path string) string {
+	var words []string
+	if prefix != "" {
+		words = append(words, prefix)
+	}
+	for _, component := range strings.Split(path, "/") {
+		words = append(words, splitWords(component)...)
+	}
+	return strings.ToUpper(strings.Join(words, "_"))
+}
+
+// FlagName returns the command-line flag name for path, which consists of the
+// words in the path in lower case. Words in a path component are separated by
+// dashes, and path components are separated by dots.
+func FlagName(path string) string {
+	var components []string
+	for _, component := range strings.Split(path, "/") {
+		if words := splitWords(component); len(words) != 0 {
+			components = append(components, strings.Join(words, "-"))
+		}
+	}
+	return strings.ToLower(strings.Join(components, "."))
+}
+
+// splitWords returns the words in s, with letters and digits only. Camel case
+// marks the start of a word, with acronyms like "HTTPPort" as "HTTP" "Port".
+// Acronyms with lower case letters before a digit, like "IPv6", stay whole.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) != 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+
+		if len(word) != 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower && !endsInDigit(runes[i+1:]) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) != 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// endsInDigit returns whether the lower case letters at the start of runes are
+// followed by a digit.
+func endsInDigit(runes []rune) bool {
+	for _, r := range runes {
+		if !unicode.IsLower(r) {
+			return unicode.IsDigit(r)
+		}
+	}
+	return false
+}
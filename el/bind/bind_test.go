@@ -0,0 +1,158 @@
+package bind
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+type server struct {
+	Port     uint16        `el:"/Server/Port"`
+	MaxConns int           `el:"/Server/MaxConns"`
+	Timeout  time.Duration `el:"/Server/Timeout"`
+}
+
+type config struct {
+	Server  *server
+	Debug   bool `el:"/Debug"`
+	Labels  map[string]string
+	Ignored string `el:"-"`
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct{ prefix, path, env, flag string }{
+		{"APP", "/Server/Port", "APP_SERVER_PORT", "server.port"},
+		{"", "/Server/MaxConns", "SERVER_MAX_CONNS", "server.max-conns"},
+		{"APP", "/HTTPPort", "APP_HTTP_PORT", "http-port"},
+		{"APP", "/TLS/CertFile", "APP_TLS_CERT_FILE", "tls.cert-file"},
+		{"X", `/Labels["env"]`, "X_LABELS_ENV", "labels-env"},
+		{"X", "/IPv6", "X_IPV6", "ipv6"},
+		{"X", "/ListenIPv6Addr", "X_LISTEN_IPV6_ADDR", "listen-ipv6-addr"},
+		{"X", "/OAuth2", "X_OAUTH2", "oauth2"},
+		{"X", "/HTTPServer", "X_HTTP_SERVER", "http-server"},
+	}
+	for _, test := range tests {
+		if got := EnvName(test.prefix, test.path); got != test.env {
+			t.Errorf("%q %q: got environment variable %q, want %q", test.prefix, test.path, got, test.env)
+		}
+		if got := FlagName(test.path); got != test.flag {
+			t.Errorf("%q: got flag %q, want %q", test.path, got, test.flag)
+		}
+	}
+}
+
+func TestBindings(t *testing.T) {
+	b, err := New(new(config), "APP", Binding{Path: `/Labels["env"]`, Env: "ENV"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "bindings", b.Bindings(), []Binding{
+		{Path: "/Server/Port", Env: "APP_SERVER_PORT", Flag: "server.port"},
+		{Path: "/Server/MaxConns", Env: "APP_SERVER_MAX_CONNS", Flag: "server.max-conns"},
+		{Path: "/Server/Timeout", Env: "APP_SERVER_TIMEOUT", Flag: "server.timeout"},
+		{Path: "/Debug", Env: "APP_DEBUG", Flag: "debug"},
+		{Path: `/Labels["env"]`, Env: "ENV"},
+	})
+
+	if _, err := New(config{}, ""); err == nil {
+		t.Error("no error for non-pointer configuration")
+	}
+	if _, err := New(new(config), "", Binding{Path: "/Server/Prot"}); err == nil {
+		t.Error("no error for path without match")
+	}
+}
+
+func TestEnvAndFlags(t *testing.T) {
+	t.Setenv("APP_SERVER_PORT", "8080")
+	t.Setenv("APP_SERVER_TIMEOUT", "1m")
+	t.Setenv("APP_DEBUG", "maybe")
+	t.Setenv("APP_SERVER_MAX_CONNS", "")
+	t.Setenv("ENV", "prod")
+
+	c := new(config)
+	b, err := New(c, "APP", Binding{Path: `/Labels["env"]`, Env: "ENV"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Env()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err := b.Flags(fs); err != nil {
+		t.Fatal(err)
+	}
+	err = fs.Parse([]string{"-server.max-conns", "100", "-server.port", "99999", "-debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verify.Values(t, "config", c, &config{
+		Server: &server{Port: 8080, MaxConns: 100, Timeout: time.Minute},
+		Debug:  true,
+		Labels: map[string]string{"env": "prod"},
+	})
+
+	errs, ok := b.Err().(Errors)
+	if !ok {
+		t.Fatalf("got error %#v, want Errors", b.Err())
+	}
+	verify.Values(t, "errors", errs, Errors{
+		{Key: "$APP_SERVER_MAX_CONNS", Path: "/Server/MaxConns", Value: ""},
+		{Key: "$APP_DEBUG", Path: "/Debug", Value: "maybe"},
+		{Key: "-server.port", Path: "/Server/Port", Value: "99999"},
+	})
+	const want = `goe bind: $APP_SERVER_MAX_CONNS value "" not applicable to /Server/MaxConns
+goe bind: $APP_DEBUG value "maybe" not applicable to /Debug
+goe bind: -server.port value "99999" not applicable to /Server/Port`
+	if got := errs.Error(); got != want {
+		t.Errorf("got error message %q, want %q", got, want)
+	}
+}
+
+func TestFlagDefaults(t *testing.T) {
+	c := &config{Server: &server{Port: 80}}
+	b, err := New(c, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := b.Flags(fs); err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.Lookup("server.port").DefValue; got != "80" {
+		t.Errorf("got default %q, want 80", got)
+	}
+	if got := fs.Lookup("debug").DefValue; got != "false" {
+		t.Errorf("got default %q, want false", got)
+	}
+	if err := b.Err(); err != nil {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestFlagRedefinition(t *testing.T) {
+	b, err := New(new(config), "", Binding{Path: "/Labels[\"debug\"]", Flag: "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := b.Flags(fs); err == nil {
+		t.Error("no error for flag -debug of two bindings")
+	}
+	if fs.Lookup("server.port") != nil {
+		t.Error("flags defined despite error")
+	}
+
+	b, err = New(new(config), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("debug", false, "")
+	if err := b.Flags(fs); err == nil {
+		t.Error("no error for flag -debug on flag set")
+	}
+}
@@ -0,0 +1,344 @@
+package el
+
+import (
+	"math"
+	"math/bits"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// not is the boolean negation of an operand, conform truth.
+type not struct{ x operand }
+
+func (n *not) eval(v reflect.Value, ev *evaluation) []reflect.Value {
+	return []reflect.Value{reflect.ValueOf(!truth(n.x.eval(v, ev)))}
+}
+
+// negation is the arithmetic negation of an operand.
+type negation struct{ x operand }
+
+func (n *negation) eval(v reflect.Value, ev *evaluation) []reflect.Value {
+	x, ok := single(n.x.eval(v, ev))
+	if !ok {
+		return nil
+	}
+	if r := calculate('-', reflect.ValueOf(int64(0)), x); r.IsValid() {
+		return []reflect.Value{r}
+	}
+	return nil
+}
+
+// arithmetic is an operator on two numbers, or the concatenation of strings.
+// Operands need exactly one value each.
+type arithmetic struct {
+	op   byte
+	x, y operand
+}
+
+func (a *arithmetic) eval(v reflect.Value, ev *evaluation) []reflect.Value {
+	x, ok := single(a.x.eval(v, ev))
+	if !ok {
+		return nil
+	}
+	y, ok := single(a.y.eval(v, ev))
+	if !ok {
+		return nil
+	}
+	if r := calculate(a.op, x, y); r.IsValid() {
+		return []reflect.Value{r}
+	}
+	return nil
+}
+
+// conditional is the ternary operator.
+type conditional struct {
+	cond, x, y operand
+}
+
+func (c *conditional) eval(v reflect.Value, ev *evaluation) []reflect.Value {
+	if truth(c.cond.eval(v, ev)) {
+		return c.x.eval(v, ev)
+	}
+	return c.y.eval(v, ev)
+}
+
+// single returns the one value present, if any.
+func single(values []reflect.Value) (v reflect.Value, ok bool) {
+	for _, x := range values {
+		x = follow(x, false)
+		if !x.IsValid() {
+			continue
+		}
+		if ok {
+			return reflect.Value{}, false
+		}
+		v, ok = x, true
+	}
+	return v, ok
+}
+
+// calculate returns x op y, with the zero Value for not applicable. Integers
+// stay integers, with uint64 when both are unsigned and the result fits, and
+// with int64 otherwise. Integer division truncates. Division by zero and
+// integer overflow have no result. Any float makes a float64.
+func calculate(op byte, x, y reflect.Value) reflect.Value {
+	xk, yk := kindClass(x.Kind()), kindClass(y.Kind())
+	switch {
+	case xk == reflect.String && yk == reflect.String:
+		if op == '+' {
+			return reflect.ValueOf(x.String() + y.String())
+		}
+
+	case xk == reflect.Uint && yk == reflect.Uint:
+		a, b := x.Uint(), y.Uint()
+		switch op {
+		case '+':
+			if sum, carry := bits.Add64(a, b, 0); carry == 0 {
+				return reflect.ValueOf(sum)
+			}
+		case '-':
+			if a >= b {
+				return reflect.ValueOf(a - b)
+			}
+			if b-a <= 1<<63 {
+				return reflect.ValueOf(-int64(b-a-1) - 1)
+			}
+		case '*':
+			if hi, lo := bits.Mul64(a, b); hi == 0 {
+				return reflect.ValueOf(lo)
+			}
+		case '/':
+			if b != 0 {
+				return reflect.ValueOf(a / b)
+			}
+		}
+
+	case (xk == reflect.Int || xk == reflect.Uint) && (yk == reflect.Int || yk == reflect.Uint):
+		a, aOK := asInt(x)
+		b, bOK := asInt(y)
+		if !aOK || !bOK {
+			return calculateFloat(op, asFloat(x), asFloat(y))
+		}
+		switch op {
+		case '+':
+			if c := a + b; (c > a) == (b > 0) {
+				return reflect.ValueOf(c)
+			}
+		case '-':
+			if c := a - b; (c < a) == (b > 0) {
+				return reflect.ValueOf(c)
+			}
+		case '*':
+			if a == 0 || b == 0 {
+				return reflect.ValueOf(int64(0))
+			}
+			if c := a * b; c/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64) {
+				return reflect.ValueOf(c)
+			}
+		case '/':
+			if b != 0 && !(a == math.MinInt64 && b == -1) {
+				return reflect.ValueOf(a / b)
+			}
+		}
+
+	case isNumber(xk) && isNumber(yk):
+		return calculateFloat(op, asFloat(x), asFloat(y))
+	}
+	return reflect.Value{}
+}
+
+func calculateFloat(op byte, a, b float64) reflect.Value {
+	switch op {
+	case '+':
+		return reflect.ValueOf(a + b)
+	case '-':
+		return reflect.ValueOf(a - b)
+	case '*':
+		return reflect.ValueOf(a * b)
+	case '/':
+		return reflect.ValueOf(a / b)
+	}
+	return reflect.Value{}
+}
+
+// asInt returns the integer value of v when it fits an int64.
+func asInt(v reflect.Value) (int64, bool) {
+	if kindClass(v.Kind()) == reflect.Int {
+		return v.Int(), true
+	}
+	u := v.Uint()
+	return int64(u), u <= math.MaxInt64
+}
+
+// call is a function invocation.
+type call struct {
+	f    *function
+	args []operand
+}
+
+func (c *call) eval(v reflect.Value, ev *evaluation) []reflect.Value {
+	args := make([][]reflect.Value, len(c.args))
+	for i, arg := range c.args {
+		for _, x := range arg.eval(v, ev) {
+			if x = follow(x, false); x.IsValid() {
+				args[i] = append(args[i], x)
+			}
+		}
+	}
+	if r := c.f.apply(args); r.IsValid() {
+		return []reflect.Value{r}
+	}
+	return nil
+}
+
+// function is a library entry. Arguments are the values of each operand, with
+// pointers and interfaces followed, and without nil values.
+type function struct {
+	minArgs, maxArgs int // maxArgs is -1 for unlimited
+	apply            func(args [][]reflect.Value) reflect.Value
+}
+
+// functions has the library available to expressions.
+var functions = map[string]*function{
+	"len":      {1, 1, lenFunc},
+	"count":    {1, 1, countFunc},
+	"sum":      {1, 1, sumFunc},
+	"min":      {1, -1, minFunc},
+	"max":      {1, -1, maxFunc},
+	"contains": {2, 2, containsFunc},
+	"matches":  {2, 2, matchesFunc},
+	"lower":    {1, 1, lowerFunc},
+}
+
+// lenFunc returns the length of a string, an array, a slice or a map. Any
+// other value counts as one. Arguments which may have multiple values by their
+// notation, as in "len(/Nodes[*])", parse as count instead, such that the
+// outcome does not depend on the number of matches.
+func lenFunc(args [][]reflect.Value) reflect.Value {
+	if len(args[0]) == 1 {
+		switch v := args[0][0]; v.Kind() {
+		case reflect.String, reflect.Array, reflect.Slice, reflect.Map, reflect.Chan:
+			return reflect.ValueOf(int64(v.Len()))
+		}
+	}
+	return countFunc(args)
+}
+
+// countFunc returns the number of values.
+func countFunc(args [][]reflect.Value) reflect.Value {
+	return reflect.ValueOf(int64(len(args[0])))
+}
+
+// sumFunc returns the total of the numbers, conform calculate. Overflow has
+// no result.
+func sumFunc(args [][]reflect.Value) reflect.Value {
+	total := reflect.ValueOf(int64(0))
+	for _, v := range args[0] {
+		if isNumber(kindClass(v.Kind())) {
+			total = calculate('+', total, v)
+			if !total.IsValid() {
+				return total
+			}
+		}
+	}
+	return total
+}
+
+// minFunc returns the lowest of all values, conform compare.
+func minFunc(args [][]reflect.Value) reflect.Value {
+	return extreme(args, "<")
+}
+
+// maxFunc returns the highest of all values, conform compare.
+func maxFunc(args [][]reflect.Value) reflect.Value {
+	return extreme(args, ">")
+}
+
+func extreme(args [][]reflect.Value, op string) reflect.Value {
+	var pick reflect.Value
+	for _, values := range args {
+		for _, v := range values {
+			if !pick.IsValid() || compare(v, pick, op) {
+				pick = v
+			}
+		}
+	}
+	return pick
+}
+
+// containsFunc returns whether a string contains a substring, or whether any
+// of the values, or the elements of a single array or slice, equals any of
+// the values of the second argument.
+func containsFunc(args [][]reflect.Value) reflect.Value {
+	hay, needles := args[0], args[1]
+	if len(hay) == 1 && len(needles) == 1 && hay[0].Kind() == reflect.String && needles[0].Kind() == reflect.String {
+		return reflect.ValueOf(strings.Contains(hay[0].String(), needles[0].String()))
+	}
+
+	if len(hay) == 1 && (hay[0].Kind() == reflect.Array || hay[0].Kind() == reflect.Slice) {
+		elements := make([]reflect.Value, hay[0].Len())
+		for i := range elements {
+			elements[i] = hay[0].Index(i)
+		}
+		hay = elements
+	}
+	for _, v := range hay {
+		for _, needle := range needles {
+			if compare(v, needle, "==") {
+				return reflect.ValueOf(true)
+			}
+		}
+	}
+	return reflect.ValueOf(false)
+}
+
+// patterns has the compiled regular expressions per source, from literals
+// only, such that the cache is bound by the expressions in use.
+var patterns sync.Map
+
+// compilePattern returns the regular expression of a literal, cached.
+func compilePattern(src string) (*regexp.Regexp, error) {
+	if cached, ok := patterns.Load(src); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	pattern, err := regexp.Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(src, pattern)
+	return pattern, nil
+}
+
+// matchesFunc returns whether a string matches a regular expression. Malformed
+// expressions have no result.
+func matchesFunc(args [][]reflect.Value) reflect.Value {
+	s, ok := single(args[0])
+	if !ok || s.Kind() != reflect.String {
+		return reflect.Value{}
+	}
+	src, ok := single(args[1])
+	if !ok || src.Kind() != reflect.String {
+		return reflect.Value{}
+	}
+
+	pattern, ok := patterns.Load(src.String())
+	if !ok {
+		var err error
+		pattern, err = regexp.Compile(src.String())
+		if err != nil {
+			return reflect.Value{}
+		}
+	}
+	return reflect.ValueOf(pattern.(*regexp.Regexp).MatchString(s.String()))
+}
+
+// lowerFunc returns a string in lower case.
+func lowerFunc(args [][]reflect.Value) reflect.Value {
+	s, ok := single(args[0])
+	if !ok || s.Kind() != reflect.String {
+		return reflect.Value{}
+	}
+	return reflect.ValueOf(strings.ToLower(s.String()))
+}
@@ -0,0 +1,223 @@
+package el
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+type cluster struct {
+	Name    string
+	Nodes   []*clusterNode
+	Cache   struct{ TTL int }
+	Weights map[string]float64
+	Limit   uint16
+	Primary string
+	Replica string
+}
+
+type clusterNode struct {
+	Host string
+	Up   bool
+	Load uint8
+}
+
+var testCluster = &cluster{
+	Name: "DB-7",
+	Nodes: []*clusterNode{
+		{Host: "a", Up: true, Load: 10},
+		{Host: "b", Load: 80},
+		{Host: "c", Up: true, Load: 30},
+		{Host: "d", Up: true, Load: 50},
+	},
+	Cache:   struct{ TTL int }{TTL: 90},
+	Weights: map[string]float64{"a": 0.5, "b": 1.5},
+	Limit:   100,
+	Primary: "a",
+	Replica: "b",
+}
+
+func TestExpressions(t *testing.T) {
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		// literals and logic
+		{`42`, int64(42)},
+		{`"x"`, "x"},
+		{`true && !false`, true},
+		{`!/Nodes[0]/Up`, false},
+		{`!/Absent`, true},
+		{`/Limit==100`, true},
+		{`/Limit!=100`, false},
+		{`/Limit>0&&/Limit<=99`, false},
+		{`/Limit<0||/Name=="DB-7"`, true},
+		{`len(/Nodes[*]) > 3 && /Cache/TTL >= 60`, true},
+		{`len(/Nodes[*]) > 4 || /Cache/TTL < 60`, false},
+
+		// arithmetic
+		{`/Cache/TTL / 60`, int64(1)},
+		{`/Cache/TTL * 2 + 1`, int64(181)},
+		{`(/Cache/TTL + 10) * 2`, int64(200)},
+		{`/Cache/TTL - 100`, int64(-10)},
+		{`-/Cache/TTL`, int64(-90)},
+		{`/Limit + 1`, int64(101)},
+		{`/Limit + /Limit`, uint64(200)},
+		{`/Limit - 101`, int64(-1)},
+		{`/Limit * /Cache/TTL`, int64(9000)},
+		{`/Cache/TTL / 4.0`, 22.5},
+		{`/Weights["b"] * 2`, 3.0},
+		{`/Name + "-" + /Primary`, "DB-7-a"},
+		{`1 - 2 - 3`, int64(-4)},
+		{`8 / 2 / 2`, int64(2)},
+		{`/Cache/TTL / 0`, nil},
+		{`9223372036854775807 + 1`, nil},
+		{`-9223372036854775807 - 2`, nil},
+		{`-9223372036854775807 - 1`, int64(math.MinInt64)},
+		{`4294967296 * 4294967296`, nil},
+		{`-4294967296 * 2147483648`, int64(math.MinInt64)},
+		{`(-9223372036854775807 - 1) * -1`, nil},
+		{`(-9223372036854775807 - 1) / -1`, nil},
+		{`/Limit * 9223372036854775807`, nil},
+		{`sum(/Nodes[*]/Load) + 9223372036854775637`, int64(math.MaxInt64)},
+		{`sum(/Nodes[*]/Load) + 9223372036854775638`, nil},
+		{`/Limit + 18446744073709551515`, uint64(math.MaxUint64)},
+		{`/Limit + 18446744073709551516`, nil},
+		{`/Limit * 184467440737095517`, nil},
+		{`/Nodes[*]/Load + 1`, nil},
+		{`/Name * 2`, nil},
+
+		// conditional
+		{`/Cache/TTL > 60 ? /Primary : /Replica`, "a"},
+		{`/Cache/TTL > 90 ? /Primary : /Replica`, "b"},
+		{`/Absent ? 1 : 2 + 3`, int64(5)},
+
+		// functions
+		{`len(/Name)`, int64(4)},
+		{`len(/Nodes)`, int64(4)},
+		{`len(/Weights)`, int64(2)},
+		{`len(/Nodes[?Up])`, int64(3)},
+		{`len(/**/Name)`, int64(1)},
+		{`len(/Nodes[0:1]/Host)`, int64(1)},
+		{`len(/Absent ? /Nodes[*] : /Name)`, int64(1)},
+		{`count(/Nodes[?Up]/Host)`, int64(3)},
+		{`count(/Absent)`, int64(0)},
+		{`sum(/Nodes[*]/Load)`, int64(170)},
+		{`sum(/Weights[*])`, 2.0},
+		{`sum(/Absent)`, int64(0)},
+		{`min(/Nodes[*]/Load)`, uint8(10)},
+		{`max(/Nodes[*]/Load, 99)`, int64(99)},
+		{`max(/Nodes[*]/Host)`, "d"},
+		{`min(/Absent)`, nil},
+		{`contains(/Name, "B-")`, true},
+		{`contains(/Nodes[*]/Host, "c")`, true},
+		{`contains(/Nodes[*]/Host, "e")`, false},
+		{`matches(/Name, "^DB-[0-9]+$")`, true},
+		{`matches(lower(/Name), "^DB")`, false},
+		{`lower(/Name)`, "db-7"},
+		{`lower(/Absent)`, nil},
+	}
+
+	for _, test := range tests {
+		got := Any(test.expr, testCluster)
+		switch {
+		case test.want == nil:
+			if len(got) != 0 {
+				t.Errorf("%s: got %#v, want no result", test.expr, got)
+			}
+		case len(got) != 1:
+			t.Errorf("%s: got %#v, want %#v", test.expr, got, test.want)
+		default:
+			// Any normalizes to the widest type
+			want := reflect.ValueOf(test.want)
+			if reflect.TypeOf(got[0]) != want.Type() && want.CanConvert(reflect.TypeOf(got[0])) {
+				want = want.Convert(reflect.TypeOf(got[0]))
+			}
+			if got[0] != want.Interface() {
+				t.Errorf("%s: got %#v, want %#v", test.expr, got[0], test.want)
+			}
+		}
+
+		// typed results retain the type from the calculation
+		if test.want != nil && reflect.TypeOf(test.want).Kind() != reflect.String {
+			values := eval(test.expr, testCluster, nil)
+			if len(values) != 1 || values[0].Type() != reflect.TypeOf(test.want) {
+				t.Errorf("%s: got values %v, want type %T", test.expr, values, test.want)
+			}
+		}
+	}
+}
+
+func TestExpressionFilters(t *testing.T) {
+	got := Strings(`/Nodes[?Load * 2 > 50 && !Up]/Host`, testCluster)
+	if len(got) != 1 || got[0] != "b" {
+		t.Errorf("got %q, want b", got)
+	}
+	got = Strings(`/Nodes[?contains(Host, "c") || Load == 10]/Host`, testCluster)
+	if len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("got %q, want a and c", got)
+	}
+}
+
+func TestExpressionCompile(t *testing.T) {
+	x := MustCompile(`max(/Nodes[*]/Load) - min(/Nodes[*]/Load)`)
+	if x.IsPath() {
+		t.Error("expression is a path")
+	}
+	if got, ok := x.Uint(testCluster); !ok || got != 70 {
+		t.Errorf("got %d, %t, want 70", got, ok)
+	}
+	if !MustCompile("/Nodes[?Load > 10]").IsPath() {
+		t.Error("path with filter is not a path")
+	}
+
+	// modification applies to paths only
+	if n := Assign(testCluster, `true ? /Name : /Primary`, "x"); n != 0 {
+		t.Errorf("assigned %d times", n)
+	}
+	if n := x.Delete(testCluster); n != 0 {
+		t.Errorf("deleted %d times", n)
+	}
+	if matches := x.Locate(testCluster); matches != nil {
+		t.Errorf("located %v", matches)
+	}
+	if r := x.Explain(testCluster); r.Err == nil {
+		t.Error("no explanation error")
+	}
+	if f, ok := Float(`-/Weights["a"] - 1`, testCluster); !ok || f != -1.5 {
+		t.Errorf("got %g, %t, want -1.5", f, ok)
+	}
+	if f, ok := Float(`/Cache/TTL / 0.0`, testCluster); !ok || !math.IsInf(f, 1) {
+		t.Errorf("got %g, %t, want +Inf", f, ok)
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	for _, expr := range []string{
+		`len(/Name`,
+		`len(/Name, /Name)`,
+		`contains(/Name)`,
+		`upper(/Name)`,
+		`/Cache/TTL >`,
+		`true ? 1`,
+		`Name == "x"`,
+		`matches(/Name, "(")`,
+		`(1 + 2`,
+		`1 2`,
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("%s: no compile error", expr)
+		}
+	}
+}
+
+func TestCheckExpression(t *testing.T) {
+	typ := reflect.TypeOf(testCluster)
+	if err := Check(`len(/Nodes[?Up]) > 1 ? /Primary : /Replica`, typ); err != nil {
+		t.Error(err)
+	}
+	const want = `goe el: expression "count(/Nodes[*]/Hots)": component /Hots has no match on type el.clusterNode`
+	if err := Check(`count(/Nodes[*]/Hots)`, typ); err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,403 @@
+package el
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Paths returns the path of each field, element and map value in type t, in
+// order of appearance. Elements and map values go by the "[*]" wildcard. Types
+// which contain themselves are not followed again, and interfaces are not
+// followed at all, since their content depends on the value. A nil type has
+// no paths.
+func Paths(t reflect.Type) []string {
+	if t == nil {
+		return nil
+	}
+	return appendPaths(nil, "", false, t, make(map[reflect.Type]bool))
+}
+
+// appendPaths appends the paths in t, located at path, to dst. Keyed tells
+// whether path ends with a key selection.
+func appendPaths(dst []string, path string, keyed bool, t reflect.Type, visiting map[reflect.Type]bool) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if visiting[t] {
+		return dst
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		visiting[t] = true
+		for i, n := 0, t.NumField(); i < n; i++ {
+			f := t.Field(i)
+			p := path + "/" + f.Name
+			dst = append(dst, p)
+			dst = appendPaths(dst, p, false, f.Type, visiting)
+		}
+		delete(visiting, t)
+
+	case reflect.Array, reflect.Slice, reflect.Map:
+		visiting[t] = true
+		p := path
+		if p == "" || keyed {
+			p += "/."
+		}
+		p += "[*]"
+		dst = append(dst, p)
+		dst = appendPaths(dst, p, true, t.Elem(), visiting)
+		delete(visiting, t)
+
+	}
+	return dst
+}
+
+// Check returns an error when expr can not have any result on values of type
+// t. The error identifies the first path component without a match. Content
+// behind interfaces can not be verified, and is assumed to match. A nil type
+// is an error.
+func Check(expr string, t reflect.Type) error {
+	if t == nil {
+		return errNilType(expr)
+	}
+	if !isPath(expr) {
+		x, err := parseOperation("expression", expr)
+		if err != nil {
+			return err
+		}
+		return checkOperand(expr, x, t)
+	}
+	path, err := parsePath(expr)
+	if err != nil {
+		return err
+	}
+	return checkPath(expr, path, t)
+}
+
+// Check is like the package-level function with the same name.
+func (x *Expr) Check(t reflect.Type) error {
+	if t == nil {
+		return errNilType(x.src)
+	}
+	if x.x != nil {
+		return checkOperand(x.src, x.x, t)
+	}
+	return checkPath(x.src, x.path, t)
+}
+
+// CheckTarget is like Check, with the additional requirement that expr selects
+// a single value, which Assign can set, on values of type t. The return is the
+// type of the target. Wildcards, filters, ranges, recursive descents and method
+// invocations have no single target, and content behind interfaces can not be
+// verified.
+func CheckTarget(expr string, t reflect.Type) (reflect.Type, error) {
+	if t == nil {
+		return nil, errNilType(expr)
+	}
+	if !isPath(expr) {
+		return nil, fmt.Errorf("goe el: expression %q is not a path", expr)
+	}
+	path, err := parsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPath(expr, path, t); err != nil {
+		return nil, err
+	}
+	return targetType(expr, path, t)
+}
+
+// targetType returns the type of the single settable target of path on t, with
+// path verified by checkPath already.
+func targetType(expr string, path []segment, t reflect.Type) (reflect.Type, error) {
+	settable := false
+	// deref follows pointers, which are settable by instantiation.
+	deref := func(seg *segment) error {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+			settable = true
+		}
+		if t.Kind() == reflect.Interface {
+			return fmt.Errorf("goe el: expression %q: component %s applies to interface %s, which can not be verified", expr, seg, t)
+		}
+		return nil
+	}
+
+	for i := range path {
+		seg := &path[i]
+		if err := deref(seg); err != nil {
+			return nil, err
+		}
+		switch {
+		case seg.descent, seg.call, seg.tagAny, seg.filter != nil, seg.key == "*",
+			seg.tag == "" && seg.selection == "*",
+			seg.elements != nil && !seg.elements.single:
+			return nil, fmt.Errorf("goe el: expression %q: component %s has no single target", expr, seg)
+		}
+
+		if seg.selection != "" || seg.tag != "" {
+			switch t.Kind() {
+			case reflect.Struct:
+				index := seg.fieldIndex(t)
+				for j, x := range index {
+					f := t.Field(x)
+					if !f.IsExported() && (j == len(index)-1 || f.Type.Kind() == reflect.Ptr) {
+						return nil, fmt.Errorf("goe el: expression %q: component %s selects field %s of type %s, which is not exported", expr, seg, f.Name, t)
+					}
+					t = f.Type
+					if j < len(index)-1 {
+						for t.Kind() == reflect.Ptr {
+							t = t.Elem()
+							settable = true
+						}
+					}
+				}
+			case reflect.Map, reflect.Slice:
+				t = t.Elem()
+				settable = true
+			case reflect.Array:
+				t = t.Elem()
+			}
+		}
+
+		if seg.key != "" {
+			if err := deref(seg); err != nil {
+				return nil, err
+			}
+			switch t.Kind() {
+			case reflect.Map, reflect.Slice:
+				t = t.Elem()
+				settable = true
+			case reflect.Array:
+				t = t.Elem()
+			default:
+				return nil, fmt.Errorf("goe el: expression %q: component %s selects from type %s, which is not settable", expr, seg, t)
+			}
+		}
+	}
+	if !settable {
+		return nil, fmt.Errorf("goe el: expression %q has no settable target on type %s", expr, t)
+	}
+	return t, nil
+}
+
+// errNilType returns the error for a check of expr on a nil type.
+func errNilType(expr string) error {
+	return fmt.Errorf("goe el: expression %q checked against nil type", expr)
+}
+
+// checkPath verifies path on t for expr.
+func checkPath(expr string, path []segment, t reflect.Type) error {
+	types := []reflect.Type{t}
+	for i := range path {
+		seg := &path[i]
+
+		// dereference & detect dynamic content
+		for j, t := range types {
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() == reflect.Interface {
+				return nil
+			}
+			types[j] = t
+		}
+
+		var next []reflect.Type
+		switch {
+		case seg.descent:
+			var dynamic bool
+			next, dynamic = reachableTypes(types)
+			if dynamic {
+				return nil
+			}
+		case seg.selection != "" || seg.tag != "":
+			for _, t := range types {
+				next = append(next, seg.fieldTypes(t)...)
+			}
+		default:
+			next = types
+		}
+
+		if seg.key != "" && len(next) != 0 {
+			types, next = next, nil
+			for _, t := range types {
+				for t.Kind() == reflect.Ptr {
+					t = t.Elem()
+				}
+				if t.Kind() == reflect.Interface {
+					return nil
+				}
+
+				e, err := seg.keyType(expr, t)
+				if err != nil {
+					return err
+				}
+				if e != nil {
+					next = append(next, e)
+				}
+			}
+		}
+
+		if len(next) == 0 {
+			names := make([]string, len(types))
+			for i, t := range types {
+				names[i] = t.String()
+			}
+			return fmt.Errorf("goe el: expression %q: component %s has no match on type %s", expr, seg, strings.Join(names, ", "))
+		}
+		types = next
+	}
+	return nil
+}
+
+// fieldTypes returns the types matching the field selection of seg on t.
+func (seg *segment) fieldTypes(t reflect.Type) []reflect.Type {
+	if seg.call {
+		if r := seg.methodType(t); r != nil {
+			return []reflect.Type{r}
+		}
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		switch {
+		case seg.tagAny:
+			var a []reflect.Type
+			for _, f := range wireFields(t, seg.tag) {
+				a = append(a, t.FieldByIndex(f.index).Type)
+			}
+			return a
+		case seg.tag == "" && seg.selection == "*":
+			a := make([]reflect.Type, t.NumField())
+			for i := range a {
+				a[i] = t.Field(i).Type
+			}
+			return a
+		}
+		if index := seg.fieldIndex(t); index != nil {
+			return []reflect.Type{t.FieldByIndex(index).Type}
+		}
+
+	case reflect.Map:
+		if seg.tag != "" && (seg.tagAny || wireKey(seg.selection, t.Key()).IsValid()) {
+			return []reflect.Type{t.Elem()}
+		}
+
+	case reflect.Array, reflect.Slice:
+		if seg.tag == "" {
+			break
+		}
+		if seg.tagAny {
+			return []reflect.Type{t.Elem()}
+		}
+		if i, ok := wireIndex(seg.selection); ok && (t.Kind() == reflect.Slice || i < t.Len()) {
+			return []reflect.Type{t.Elem()}
+		}
+
+	}
+	return nil
+}
+
+// keyType returns the type matching the key selection of seg on t, with nil
+// for none. Errors come from filters only.
+func (seg *segment) keyType(expr string, t reflect.Type) (reflect.Type, error) {
+	var e reflect.Type
+	switch t.Kind() {
+	case reflect.Array, reflect.Slice, reflect.String:
+		if t.Kind() == reflect.String {
+			e = reflect.TypeOf(byte(0))
+		} else {
+			e = t.Elem()
+		}
+
+		switch {
+		case seg.filter != nil:
+			return e, checkOperand(expr, seg.filter, e)
+		case seg.key == "*", seg.elements != nil:
+			return e, nil
+		case seg.index >= 0:
+			if t.Kind() == reflect.Array && seg.index >= t.Len() {
+				return nil, nil
+			}
+			return e, nil
+		}
+
+	case reflect.Map:
+		e = t.Elem()
+		switch {
+		case seg.filter != nil:
+			return e, checkOperand(expr, seg.filter, e)
+		case seg.key == "*":
+			return e, nil
+		case seg.elements != nil && !seg.elements.single:
+			return nil, nil
+		case seg.mapKey(t.Key()) != nil:
+			return e, nil
+		}
+
+	}
+	return nil, nil
+}
+
+// checkOperand verifies the paths in operand op on context type t.
+func checkOperand(expr string, op operand, t reflect.Type) error {
+	var children []operand
+	switch op := op.(type) {
+	case relPath:
+		return checkPath(expr, op, t)
+	case *logical:
+		children = []operand{op.x, op.y}
+	case *comparison:
+		children = []operand{op.x, op.y}
+	case *arithmetic:
+		children = []operand{op.x, op.y}
+	case *not:
+		children = []operand{op.x}
+	case *negation:
+		children = []operand{op.x}
+	case *conditional:
+		children = []operand{op.cond, op.x, op.y}
+	case *call:
+		children = op.args
+	}
+	for _, x := range children {
+		if err := checkOperand(expr, x, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reachableTypes returns types and their content types, recursively. Dynamic
+// is set when any of them is an interface.
+func reachableTypes(types []reflect.Type) (reachable []reflect.Type, dynamic bool) {
+	seen := make(map[reflect.Type]bool)
+	for len(types) != 0 {
+		t := types[len(types)-1]
+		types = types[:len(types)-1]
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		reachable = append(reachable, t)
+
+		switch t.Kind() {
+		case reflect.Interface:
+			dynamic = true
+		case reflect.Struct:
+			for i, n := 0, t.NumField(); i < n; i++ {
+				types = append(types, t.Field(i).Type)
+			}
+		case reflect.Array, reflect.Slice, reflect.Map:
+			types = append(types, t.Elem())
+		}
+	}
+	return reachable, dynamic
+}
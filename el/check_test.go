@@ -0,0 +1,174 @@
+package el
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestPathEnumeration(t *testing.T) {
+	type node struct {
+		Name     string
+		Children []*node
+		Labels   map[string][]int
+		Any      interface{}
+	}
+
+	want := []string{
+		"/Name",
+		"/Children",
+		"/Children[*]",
+		"/Labels",
+		"/Labels[*]",
+		"/Labels[*]/.[*]",
+		"/Any",
+	}
+	verify.Values(t, "node paths", Paths(reflect.TypeOf(&node{})), want)
+	verify.Values(t, "slice paths", Paths(reflect.TypeOf([]Meta{})), []string{"/.[*]", "/.[*]/Owner", "/.[*]/Version"})
+
+	for _, p := range Paths(reflect.TypeOf(Cache{})) {
+		if err := Check(p, reflect.TypeOf(Cache{})); err != nil {
+			t.Errorf("path %q: %s", p, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	typ := reflect.TypeOf(&struct {
+		Cache   *Cache
+		Orders  []Order
+		Arr     [2]int
+		ByID    map[int]string
+		Dynamic interface{}
+	}{})
+
+	valid := []string{
+		"/",
+		"/Cache/TTL",
+		"/Cache/Owner",
+		"/Cache/@json:owner",
+		"/Cache/@json:*",
+		"/Cache/Labels[*]",
+		`/Cache/Labels["x"]`,
+		"/Cache/@json:labels/@json:x",
+		"/*",
+		"/Orders[-1]/Ship/Zone",
+		"/Orders[1:3]/Items[0]",
+		`/Orders[?Ship/Zone > 1 && ID == "x"]/Total`,
+		"/Arr[1]",
+		"/ByID[7]",
+		"/ByID[-1]",
+		"/**/Zone",
+		"/Dynamic/Anything[9]",
+		"/**/Anything",
+	}
+	for _, expr := range valid {
+		if err := Check(expr, typ); err != nil {
+			t.Errorf("%q: %s", expr, err)
+		}
+	}
+
+	invalid := []struct{ expr, segment string }{
+		{"/Cahce/TTL", "/Cahce"},
+		{"/Cache/TTL/X", "/X"},
+		{"/Cache/@json:TTL", "/@json:TTL"},
+		{`/Cache/Labels[7]`, "/Labels[7]"},
+		{"/Arr[2]", "/Arr[2]"},
+		{`/ByID["x"]`, `/ByID["x"]`},
+		{"/ByID[1:2]", "/ByID[1:2]"},
+		{"/Orders[?Totl > 1]", "/Totl"},
+		{"/Orders[*]/Items/Mis", "/Mis"},
+	}
+	for _, test := range invalid {
+		err := Check(test.expr, typ)
+		if err == nil {
+			t.Errorf("%q: no error", test.expr)
+			continue
+		}
+		if !strings.Contains(err.Error(), "component "+test.segment+" ") {
+			t.Errorf("%q: got error %q, want component %s", test.expr, err, test.segment)
+		}
+	}
+
+	if err := MustCompile("/Cache/Plain").Check(typ); err != nil {
+		t.Error("compiled:", err)
+	}
+	if err := Check("Cache", typ); err == nil {
+		t.Error("no error for malformed expression")
+	}
+	if err := Check("/Cache", nil); err == nil {
+		t.Error("no error for nil type")
+	}
+	if err := MustCompile("/Cache").Check(nil); err == nil {
+		t.Error("compiled: no error for nil type")
+	}
+	if got := Paths(nil); got != nil {
+		t.Errorf("got paths %q for nil type", got)
+	}
+}
+
+func TestCheckTarget(t *testing.T) {
+	typ := reflect.TypeOf(&struct {
+		Cache   *Cache
+		Orders  []Order
+		Arr     [2]int
+		ByID    map[int]string
+		Dynamic interface{}
+		private int
+	}{})
+
+	valid := []struct {
+		expr string
+		want reflect.Type
+	}{
+		{"/Cache/TTL", reflect.TypeOf(0)},
+		{"/Cache/Owner", reflect.TypeOf("")},
+		{"/Cache/Region", reflect.TypeOf("")},
+		{"/Cache/@json:cache_ttl", reflect.TypeOf(0)},
+		{`/Cache/Labels["x"]`, reflect.TypeOf("")},
+		{"/Orders[7]/Ship/Zone", reflect.TypeOf(uint8(0))},
+		{"/Orders[-1]/Items", reflect.TypeOf([]string(nil))},
+		{"/Arr[1]", reflect.TypeOf(0)},
+		{"/ByID[7]", reflect.TypeOf("")},
+	}
+	for _, test := range valid {
+		got, err := CheckTarget(test.expr, typ)
+		if err != nil {
+			t.Errorf("%q: %s", test.expr, err)
+		} else if got != test.want {
+			t.Errorf("%q: got type %s, want %s", test.expr, got, test.want)
+		}
+	}
+
+	invalid := []string{
+		"/",
+		"/Cahce/TTL",
+		"/Cache/*",
+		"/Cache/@json:*",
+		"/Cache/Labels[*]",
+		"/Cache/private",
+		"/private",
+		"/Orders[1:3]/ID",
+		`/Orders[?ID == "x"]/Total`,
+		"/Orders[0]/ID[1]",
+		"/**/Zone",
+		"/Dynamic/Anything",
+		"/Cache/Owner/Len()",
+		"len(/Orders)",
+	}
+	for _, expr := range invalid {
+		if got, err := CheckTarget(expr, typ); err == nil {
+			t.Errorf("%q: got type %s, want error", expr, got)
+		} else if !strings.HasPrefix(err.Error(), "goe el: ") {
+			t.Errorf("%q: got error %q, want package prefix", expr, err)
+		}
+	}
+	if _, err := CheckTarget("/TTL", reflect.TypeOf(Cache{})); err == nil {
+		t.Error("no error for a field of a struct which is not addressable")
+	}
+	if _, err := CheckTarget("/TTL", nil); err == nil {
+		t.Error("no error for nil type")
+	}
+}
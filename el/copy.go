@@ -0,0 +1,221 @@
+package el
+
+import (
+	"reflect"
+)
+
+// deepCopy sets dst, which must be settable, to a copy of src. Pointers, maps,
+// slices and interfaces are copied recursively, with the reference structure,
+// including cycles, preserved by seen. Unexported struct fields are copied as
+// is, since reflection can't modify them anyway. Origins, when not nil, gets
+// the original of each copied pointer, map and slice.
+func deepCopy(dst, src reflect.Value, seen, origins map[visit]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			break
+		}
+		key := visit{p: src.Pointer(), typ: src.Type()}
+		if c, ok := seen[key]; ok {
+			dst.Set(c)
+			return
+		}
+		c := reflect.New(src.Type().Elem())
+		seen[key] = c
+		if origins != nil {
+			origins[visit{p: c.Pointer(), typ: c.Type()}] = detach(src)
+		}
+		deepCopy(c.Elem(), src.Elem(), seen, origins)
+		dst.Set(c)
+		return
+
+	case reflect.Interface:
+		if src.IsNil() {
+			break
+		}
+		e := src.Elem()
+		c := reflect.New(e.Type()).Elem()
+		deepCopy(c, e, seen, origins)
+		dst.Set(c)
+		return
+
+	case reflect.Map:
+		if src.IsNil() {
+			break
+		}
+		key := visit{p: src.Pointer(), typ: src.Type()}
+		if c, ok := seen[key]; ok {
+			dst.Set(c)
+			return
+		}
+		c := reflect.MakeMapWithSize(src.Type(), src.Len())
+		seen[key] = c
+		if origins != nil {
+			origins[visit{p: c.Pointer(), typ: c.Type()}] = detach(src)
+		}
+		e := reflect.New(src.Type().Elem()).Elem()
+		for iter := src.MapRange(); iter.Next(); {
+			deepCopy(e, iter.Value(), seen, origins)
+			c.SetMapIndex(iter.Key(), e)
+		}
+		dst.Set(c)
+		return
+
+	case reflect.Slice:
+		if src.IsNil() {
+			break
+		}
+		key := visit{p: src.Pointer(), n: src.Len(), typ: src.Type()}
+		if c, ok := seen[key]; ok {
+			dst.Set(c)
+			return
+		}
+		c := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		seen[key] = c
+		if origins != nil && c.Len() != 0 {
+			origins[visit{p: c.Pointer(), n: c.Len(), typ: c.Type()}] = detach(src)
+		}
+		for i, n := 0, src.Len(); i < n; i++ {
+			deepCopy(c.Index(i), src.Index(i), seen, origins)
+		}
+		dst.Set(c)
+		return
+
+	case reflect.Array:
+		dst.Set(src)
+		for i, n := 0, src.Len(); i < n; i++ {
+			deepCopy(dst.Index(i), src.Index(i), seen, origins)
+		}
+		return
+
+	case reflect.Struct:
+		dst.Set(src)
+		t := src.Type()
+		for i, n := 0, t.NumField(); i < n; i++ {
+			if t.Field(i).IsExported() {
+				deepCopy(dst.Field(i), src.Field(i), seen, origins)
+			}
+		}
+		return
+	}
+
+	dst.Set(src)
+}
+
+// detach returns a copy of v, which is not affected by any changes to the
+// content v came from, like a struct field or a slice element.
+func detach(v reflect.Value) reflect.Value {
+	d := reflect.New(v.Type()).Elem()
+	d.Set(v)
+	return d
+}
+
+// restorer puts the content of copies from deepCopy back onto their originals.
+type restorer struct {
+	// origins has the original of each copy.
+	origins map[visit]reflect.Value
+	// done has the copies which are restored already, or in progress.
+	done map[visit]bool
+}
+
+// restore replaces each copy in v, which must be settable, with its original,
+// after the content of the copy is applied onto the original. Content without
+// an original, i.e., content which was added, remains as is, with any copies
+// inside replaced.
+func (r *restorer) restore(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		key := visit{p: v.Pointer(), typ: v.Type()}
+		o, ok := r.origins[key]
+		if r.done[key] {
+			if ok {
+				v.Set(o)
+			}
+			return
+		}
+		r.done[key] = true
+		if !ok {
+			r.restore(v.Elem())
+			return
+		}
+		o.Elem().Set(v.Elem())
+		r.restore(o.Elem())
+		v.Set(o)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		e := reflect.New(v.Elem().Type()).Elem()
+		e.Set(v.Elem())
+		r.restore(e)
+		v.Set(e)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		key := visit{p: v.Pointer(), typ: v.Type()}
+		o, ok := r.origins[key]
+		if r.done[key] {
+			if ok {
+				v.Set(o)
+			}
+			return
+		}
+		r.done[key] = true
+		if !ok {
+			o = v
+		} else {
+			for _, k := range o.MapKeys() {
+				if !v.MapIndex(k).IsValid() {
+					o.SetMapIndex(k, reflect.Value{})
+				}
+			}
+		}
+		e := reflect.New(v.Type().Elem()).Elem()
+		for iter := v.MapRange(); iter.Next(); {
+			e.Set(iter.Value())
+			r.restore(e)
+			o.SetMapIndex(iter.Key(), e)
+		}
+		v.Set(o)
+
+	case reflect.Slice:
+		if v.IsNil() || v.Len() == 0 {
+			return
+		}
+		key := visit{p: v.Pointer(), n: v.Len(), typ: v.Type()}
+		o, ok := r.origins[key]
+		if r.done[key] {
+			if ok {
+				v.Set(o)
+			}
+			return
+		}
+		r.done[key] = true
+		if ok {
+			reflect.Copy(o, v)
+			v.Set(o)
+		}
+		for i, n := 0, v.Len(); i < n; i++ {
+			r.restore(v.Index(i))
+		}
+
+	case reflect.Array:
+		for i, n := 0, v.Len(); i < n; i++ {
+			r.restore(v.Index(i))
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i, n := 0, t.NumField(); i < n; i++ {
+			if t.Field(i).IsExported() {
+				r.restore(v.Field(i))
+			}
+		}
+	}
+}
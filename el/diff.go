@@ -0,0 +1,394 @@
+package el
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Op is a modification at a path.
+type Op struct {
+	// Op is one of "add", "replace" or "remove".
+	Op string
+	// Path is the canonical expression of the location, conform Locate.
+	Path string
+	// Value is the new content, with nil for removals.
+	Value interface{}
+
+	// pointer is the JSON Pointer of the location, if known.
+	pointer string
+	// hidden marks content without a JSON representation.
+	hidden bool
+	// member marks a location in a JSON object.
+	member bool
+}
+
+// MarshalJSON implements the json.Marshaler interface with an RFC 6902 JSON
+// Patch operation. Operations from Diff have their JSON Pointer conform the
+// names of encoding/json. Others go with ToJSONPointer on their path. Content
+// without a JSON representation, like fields tagged "-", has no such notation.
+// Replacements of object members from Diff go as "add", because fields with
+// the omitempty option may be absent in JSON.
+func (op Op) MarshalJSON() ([]byte, error) {
+	if op.hidden {
+		return nil, fmt.Errorf("goe el: %s %s has no JSON representation", op.Op, op.Path)
+	}
+	ptr := op.pointer
+	if ptr == "" {
+		var err error
+		ptr, err = ToJSONPointer(op.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	name := op.Op
+	switch {
+	case name == "remove":
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{name, ptr})
+	case name == "replace" && op.member:
+		name = "add"
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{name, ptr, op.Value})
+}
+
+// Diff returns the operations which turn a into b. Structs compare per field,
+// maps per key and slices per element. Slices which differ in length get
+// additions or removals at the end, with removals in descending order, such
+// that the operations apply one after the other. Leaves are determined
+// conform Flatten, and they are replaced as a whole, just like any content
+// which differs in type or in nil-ness. Non-exported fields are ignored.
+//
+// Values are copies of the content in b. The result is deterministic, i.e.,
+// the same content gives the same operations.
+func Diff(a, b interface{}) []Op {
+	d := differ{seen: make(map[[2]visit]struct{})}
+	d.diff(diffLoc{}, reflect.ValueOf(a), reflect.ValueOf(b))
+	return d.ops
+}
+
+// diffLoc is a location with its JSON Pointer.
+type diffLoc struct {
+	located
+	pointer string
+	hidden  bool
+	member  bool
+}
+
+// field returns the location of a struct field with name in l, with wire as
+// its JSON name, if any.
+func (l diffLoc) field(name, wire string, ok bool) diffLoc {
+	return diffLoc{
+		located: l.located.field(name, reflect.Value{}),
+		pointer: l.pointer + "/" + escapeJSONPointer(wire),
+		hidden:  l.hidden || !ok,
+		member:  true,
+	}
+}
+
+// element returns the location of an element with number i in l.
+func (l diffLoc) element(i int) diffLoc {
+	return diffLoc{
+		located: l.located.element(i, reflect.Value{}),
+		pointer: l.pointer + "/" + strconv.Itoa(i),
+		hidden:  l.hidden,
+	}
+}
+
+// entry returns the location of a map entry with key k in l.
+func (l diffLoc) entry(k reflect.Value) diffLoc {
+	wire, ok := wireKeyName(k)
+	if !ok {
+		l.hidden = true
+	}
+	return diffLoc{
+		located: l.located.key(keyLiteral(k), reflect.Value{}),
+		pointer: l.pointer + "/" + escapeJSONPointer(wire),
+		hidden:  l.hidden,
+		member:  true,
+	}
+}
+
+// differ collects operations.
+type differ struct {
+	ops []Op
+	// seen has the pointer pairs compared before.
+	seen map[[2]visit]struct{}
+}
+
+// add appends an operation with content v.
+func (d *differ) add(op string, l diffLoc, v reflect.Value) {
+	path := l.path
+	if path == "" {
+		path = "/"
+	}
+
+	var x interface{}
+	if op != "remove" && v.IsValid() {
+		c := reflect.New(v.Type()).Elem()
+		deepCopy(c, v, make(map[visit]reflect.Value), nil)
+		x = c.Interface()
+	}
+	d.ops = append(d.ops, Op{Op: op, Path: path, Value: x, pointer: l.pointer, hidden: l.hidden, member: l.member})
+}
+
+// diff appends the operations which turn a into b at l.
+func (d *differ) diff(l diffLoc, a, b reflect.Value) {
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		if a.IsValid() || b.IsValid() {
+			d.add("replace", l, b)
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add("replace", l, b)
+			}
+			return
+		}
+		if a.Kind() == reflect.Ptr {
+			key := [2]visit{{p: a.Pointer(), typ: a.Type()}, {p: b.Pointer(), typ: b.Type()}}
+			if _, ok := d.seen[key]; ok {
+				return
+			}
+			d.seen[key] = struct{}{}
+		} else if a.Elem().Type() != b.Elem().Type() {
+			d.add("replace", l, b)
+			return
+		}
+		d.diff(l, a.Elem(), b.Elem())
+		return
+
+	case reflect.Struct:
+		if !hasExported(a.Type()) {
+			break // leaf
+		}
+		d.diffStruct(l, a, b, wireNames(a.Type()), nil)
+		return
+
+	case reflect.Slice:
+		if a.Type().Elem().Kind() == reflect.Uint8 {
+			break // leaf
+		}
+		if a.IsNil() != b.IsNil() {
+			d.add("replace", l, b)
+			return
+		}
+		fallthrough
+	case reflect.Array:
+		n := a.Len()
+		if b.Len() < n {
+			n = b.Len()
+		}
+		for i := 0; i < n; i++ {
+			d.diff(l.element(i), a.Index(i), b.Index(i))
+		}
+		for i := n; i < b.Len(); i++ {
+			d.add("add", l.element(i), b.Index(i))
+		}
+		for i := a.Len() - 1; i >= n; i-- {
+			d.add("remove", l.element(i), reflect.Value{})
+		}
+		return
+
+	case reflect.Map:
+		if a.IsNil() != b.IsNil() {
+			d.add("replace", l, b)
+			return
+		}
+		keys := a.MapKeys()
+		for _, k := range b.MapKeys() {
+			if !a.MapIndex(k).IsValid() {
+				keys = append(keys, k)
+			}
+		}
+		for _, k := range keys {
+			if keyLiteral(k) == "*" {
+				// no path notation for entries
+				d.add("replace", l, b)
+				return
+			}
+		}
+
+		for _, k := range sortKeys(keys) {
+			switch e, f := a.MapIndex(k), b.MapIndex(k); {
+			case !f.IsValid():
+				d.add("remove", l.entry(k), reflect.Value{})
+			case !e.IsValid():
+				d.add("add", l.entry(k), f)
+			default:
+				d.diff(l.entry(k), e, f)
+			}
+		}
+		return
+
+	case reflect.Func:
+		if a.Pointer() != b.Pointer() {
+			d.add("replace", l, b)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		d.add("replace", l, b)
+	}
+}
+
+// diffStruct appends the operations which turn struct a into b at l. Names has
+// the JSON names of the struct which embeds a at index, if any.
+func (d *differ) diffStruct(l diffLoc, a, b reflect.Value, names map[string]string, index []int) {
+	t := a.Type()
+	for i, n := 0, t.NumField(); i < n; i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // not exported
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && jsonTagName(f) == "" {
+			// promoted fields
+			e := l.field(f.Name, "", true)
+			e.pointer = l.pointer
+			x, y := a.Field(i), b.Field(i)
+			if f.Type.Kind() == reflect.Ptr {
+				if x.IsNil() || y.IsNil() {
+					e.hidden = true
+					d.diff(e, x, y)
+					continue
+				}
+				x, y = x.Elem(), y.Elem()
+			}
+			d.diffStruct(e, x, y, names, fieldIndex)
+			continue
+		}
+
+		name, ok := names[fmt.Sprint(fieldIndex)]
+		d.diff(l.field(f.Name, name, ok), a.Field(i), b.Field(i))
+	}
+}
+
+// hasExported returns whether struct type t has any exported fields.
+func hasExported(t reflect.Type) bool {
+	for i, n := 0, t.NumField(); i < n; i++ {
+		if t.Field(i).PkgPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTagName returns the name from the JSON tag of f, if any.
+func jsonTagName(f reflect.StructField) string {
+	tag := f.Tag.Get("json")
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
+
+// wireNames returns the JSON name of each field in struct type t, including
+// promoted fields, by their index path, conform encoding/json.
+func wireNames(t reflect.Type) map[string]string {
+	fields := wireFields(t, "json")
+	names := make(map[string]string, len(fields))
+	for _, f := range fields {
+		names[fmt.Sprint(f.index)] = f.name
+	}
+	return names
+}
+
+// Apply performs each operation on the value root points to, in order. The
+// operations "add" and "replace" set the value, with instantiation of absent
+// content like Assign does. Unlike Assign, the value must be assignable to the
+// exact type at the path, or convertible without loss, and nil sets the zero
+// value. Numbers convert when they fit, e.g., float64 2 to int, but not 2.5.
+// Operation "remove" follows the rules of Delete, and it fails when the path
+// has no content to delete, conform RFC 6902.
+//
+// The operations apply either as a whole or not at all. Root is left as it was
+// when the error is not nil.
+func Apply(root interface{}, ops []Op) error {
+	return atomic(root, func(root reflect.Value) error {
+		for i, op := range ops {
+			path, err := parsePath(op.Path)
+			if err != nil {
+				return err
+			}
+
+			switch op.Op {
+			case "add", "replace":
+				ev := &evaluation{build: true}
+				targets := resolveTargets(path, root, ev)
+				if len(targets) != 1 || !setTarget(targets[0], op.Value) {
+					return fmt.Errorf("goe el: operation %d: %s %s does not apply", i, op.Op, op.Path)
+				}
+				ev.finish()
+
+			case "remove":
+				if deletePath(path, root.Addr().Interface(), new(evaluation)) == 0 {
+					return fmt.Errorf("goe el: operation %d: remove %s has no target", i, op.Path)
+				}
+
+			default:
+				return fmt.Errorf("goe el: operation %d: unknown operation %q", i, op.Op)
+			}
+		}
+		return nil
+	})
+}
+
+// setTarget sets v to x, with nil for the zero value.
+func setTarget(v reflect.Value, x interface{}) bool {
+	if !v.IsValid() || !v.CanSet() {
+		return false
+	}
+	w := reflect.ValueOf(x)
+	switch {
+	case !w.IsValid():
+		v.Set(reflect.Zero(v.Type()))
+	case w.Type().AssignableTo(v.Type()):
+		v.Set(w)
+	case w.Type().ConvertibleTo(v.Type()) && convertsLossless(w, v.Type()):
+		v.Set(w.Convert(v.Type()))
+	default:
+		return false
+	}
+	return true
+}
+
+// convertsLossless returns whether w converts to t without loss of information.
+// Numbers must fit t, and other content must be of the same kind as t.
+func convertsLossless(w reflect.Value, t reflect.Type) bool {
+	if w.Kind() == t.Kind() {
+		return true
+	}
+	from, to := kindClass(w.Kind()), kindClass(t.Kind())
+	if !isNumber(from) || !isNumber(to) {
+		return false
+	}
+	// integer round trips keep the bits, regardless of the sign
+	switch {
+	case from == reflect.Int && to == reflect.Uint && w.Int() < 0:
+		return false
+	case from == reflect.Uint && to == reflect.Int && w.Uint() > math.MaxInt64:
+		return false
+	}
+	return w.Convert(t).Convert(w.Type()).Interface() == w.Interface()
+}
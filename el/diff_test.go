@@ -0,0 +1,184 @@
+package el
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+// opString returns the exported fields of op for comparison.
+func opStrings(ops []Op) []string {
+	a := make([]string, len(ops))
+	for i, op := range ops {
+		b, err := json.Marshal(op.Value)
+		if err != nil {
+			b = []byte(err.Error())
+		}
+		a[i] = op.Op + " " + op.Path + " " + string(b)
+	}
+	return a
+}
+
+func TestDiff(t *testing.T) {
+	golden := []struct {
+		a, b interface{}
+		want []string
+	}{
+		// tags, hidden and promoted fields
+		{testCaches[0], testCaches[1], []string{
+			`replace /TTL 90`,
+			`replace /Size 3`,
+			`replace /Labels null`,
+			`replace /Plain "p2"`,
+			`replace /Meta/Owner "dev"`,
+			`replace /Meta/Version 0`,
+		}},
+		// slices and maps
+		{testCluster.Nodes, []*clusterNode{{Host: "a", Up: true, Load: 10}, {Host: "b", Up: true, Load: 80}}, []string{
+			`replace /.[1]/Up true`,
+			`remove /.[3] null`,
+			`remove /.[2] null`,
+		}},
+		{testCluster.Nodes[:1], testCluster.Nodes[:2], []string{
+			`add /.[1] {"Host":"b","Up":false,"Load":80}`,
+		}},
+		{testCluster.Weights, map[string]float64{"a": 0.5, "c": 2}, []string{
+			`remove /.["b"] null`,
+			`add /.["c"] 2`,
+		}},
+		// leaves and interfaces
+		{Node{X: time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)}, Node{X: time.Date(2001, 2, 4, 0, 0, 0, 0, time.UTC)}, []string{
+			`replace /X "2001-02-04T00:00:00Z"`,
+		}},
+		{Node{X: []byte{1, 2}}, Node{X: []byte{1, 3}}, []string{`replace /X "AQM="`}},
+		{Node{X: map[string]interface{}{"x": 1.0}}, Node{X: "x"}, []string{`replace /X "x"`}},
+		{&Node{S: []interface{}{1, "x"}}, &Node{S: []interface{}{1, "y"}}, []string{`replace /S[1] "y"`}},
+		// roots
+		{testCluster, testCluster, []string{}},
+		{1, "x", []string{`replace / "x"`}},
+		{testCluster, nil, []string{"replace / null"}},
+	}
+	for _, gold := range golden {
+		verify.Values(t, fmt.Sprintf("%T", gold.a), opStrings(Diff(gold.a, gold.b)), gold.want)
+	}
+}
+
+func TestDiffJSONPatch(t *testing.T) {
+	a := testCaches[0]
+	b := &Cache{
+		TTL: 90, Size: 9, Plain: "p2", private: "x2",
+		Labels: map[string]string{"env": "test", "zone": "eu"},
+		Meta:   &Meta{Owner: "dev", Version: 2},
+		meta:   meta{Region: "eu"},
+	}
+	got, err := json.Marshal(Diff(a, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// apply to the JSON representation
+	var doc interface{}
+	if raw, err := json.Marshal(a); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyPatch(&doc, got); err != nil {
+		t.Fatalf("%s: %s", got, err)
+	}
+	var want interface{}
+	if raw, err := json.Marshal(b); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(raw, &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("JSON Patch %s got %v, want %v", got, doc, want)
+	}
+
+	if _, err := json.Marshal(Diff(testCaches[0], testCaches[1])); err == nil {
+		t.Error("no error for field tagged -")
+	}
+
+	manual, err := json.Marshal([]Op{{Op: "remove", Path: `/Labels["x"]`}, {Op: "add", Path: "/Nodes[3]", Value: 7}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const wantManual = `[{"op":"remove","path":"/Labels/x"},{"op":"add","path":"/Nodes/3","value":7}]`
+	if string(manual) != wantManual {
+		t.Errorf("got %s, want %s", manual, wantManual)
+	}
+}
+
+func TestApply(t *testing.T) {
+	a := &Cache{TTL: 60, Labels: map[string]string{"env": "test", "tier": "db"}, Meta: &Meta{Owner: "ops"}}
+	if err := Apply(a, Diff(a, testCaches[0])); err != nil {
+		t.Fatal(err)
+	}
+	want := &Cache{TTL: 60, Size: 9, Plain: "p1", Labels: map[string]string{"env": "prod"}, Meta: &Meta{Owner: "ops", Version: 2}}
+	verify.Values(t, "applied", a, want)
+
+	var nodes []*clusterNode
+	if err := Apply(&nodes, Diff(nodes, testCluster.Nodes)); err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "applied nodes", nodes, testCluster.Nodes)
+
+	// values are copies
+	if nodes[1] == testCluster.Nodes[1] {
+		t.Error("applied content shares memory with the source")
+	}
+
+	// conversions without loss only
+	if err := Apply(a, []Op{{Op: "replace", Path: "/TTL", Value: 90.0}}); err != nil {
+		t.Error("float without fraction to int:", err)
+	}
+	for _, v := range []interface{}{90.5, 1e19, uint64(1 << 63)} {
+		if err := Apply(a, []Op{{Op: "replace", Path: "/TTL", Value: v}}); err == nil {
+			t.Errorf("replace with %T %v got no error", v, v)
+		}
+	}
+	if err := Apply(a, []Op{{Op: "replace", Path: "/Plain", Value: 65}}); err == nil {
+		t.Error("int to string got no error")
+	}
+	verify.Values(t, "converted", a.TTL, 90)
+	verify.Values(t, "unconverted", a.Plain, "p1")
+}
+
+func TestApplyAtomic(t *testing.T) {
+	a := &cluster{Name: "a", Nodes: []*clusterNode{{Host: "a"}}, Weights: map[string]float64{"a": 1}}
+	ops := []Op{
+		{Op: "replace", Path: "/Name", Value: "x"},
+		{Op: "remove", Path: "/Nodes[0]"},
+		{Op: "replace", Path: "/Nodes", Value: "no slice"},
+	}
+	err := Apply(a, ops)
+	const want = `goe el: operation 2: replace /Nodes does not apply`
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+	unchanged := &cluster{Name: "a", Nodes: []*clusterNode{{Host: "a"}}, Weights: map[string]float64{"a": 1}}
+	verify.Values(t, "unchanged", a, unchanged)
+
+	ops = []Op{
+		{Op: "replace", Path: "/Name", Value: "x"},
+		{Op: "remove", Path: `/Weights["absent"]`},
+	}
+	err = Apply(a, ops)
+	const wantRemove = `goe el: operation 1: remove /Weights["absent"] has no target`
+	if err == nil || err.Error() != wantRemove {
+		t.Errorf("got error %v, want %s", err, wantRemove)
+	}
+	verify.Values(t, "unchanged", a, unchanged)
+
+	if err := Apply(a, []Op{{Op: "move", Path: "/Name"}}); err == nil {
+		t.Error("no error for unknown operation")
+	}
+	if err := Apply(a, []Op{{Op: "add", Path: "/Name["}}); err == nil {
+		t.Error("no error for malformed path")
+	}
+}
@@ -0,0 +1,301 @@
+package el
+
+import (
+	"reflect"
+)
+
+// Delete removes the content at the path on root and returns the number of
+// removals. Map entries are deleted, and slice elements are removed with the
+// order of the remaining elements preserved. Anything else, including array
+// elements, pointers, interfaces and struct fields, is set to its zero value.
+// Content which is absent or zero already does not count as a removal.
+//
+// Unlike Assign, Delete does not instantiate any content on the path. The same
+// requirements for settability apply.
+func Delete(root interface{}, path string) (n int) {
+	p, err := parsePath(path)
+	if err != nil {
+		return 0
+	}
+	return deletePath(p, root, new(evaluation))
+}
+
+// Delete is like the package-level function with the same name.
+func (x *Expr) Delete(root interface{}) (n int) {
+	if x.x != nil {
+		return 0
+	}
+	ev := x.constrain(new(evaluation))
+	if !x.permits(ev) {
+		return 0
+	}
+	return deletePath(x.path, root, ev)
+}
+
+// deletePath removes the content at path on root.
+func deletePath(path []segment, root interface{}, ev *evaluation) (n int) {
+	if len(path) == 0 {
+		return 0
+	}
+	last := &path[len(path)-1]
+	if last.descent || last.call {
+		return 0
+	}
+
+	track := resolve(path[:len(path)-1], root, ev)
+	if last.key == "" {
+		for _, v := range track {
+			if ev.voided() {
+				break
+			}
+			n += deleteSelection(v, last, ev)
+		}
+	} else {
+		if last.selection != "" || last.tag != "" {
+			track = followField(track, last, ev)
+		}
+		for _, v := range track {
+			n += deleteKeys(v, last, ev)
+		}
+	}
+
+	ev.finish()
+	return n
+}
+
+// deleteSelection removes the field selection of seg from v, as far as the
+// policy of ev permits.
+func deleteSelection(v reflect.Value, seg *segment, ev *evaluation) (n int) {
+	v = follow(v, false)
+	switch v.Kind() {
+	case reflect.Struct:
+		var indices [][]int
+		switch {
+		case seg.tagAny:
+			for _, f := range wireFields(v.Type(), seg.tag) {
+				indices = append(indices, f.index)
+			}
+		case seg.tag == "" && seg.selection == "*":
+			for i, n := 0, v.NumField(); i < n; i++ {
+				indices = append(indices, []int{i})
+			}
+		default:
+			if index := seg.fieldIndex(v.Type()); index != nil {
+				if !ev.permitsField(v.Type(), index) {
+					ev.deny()
+					return 0
+				}
+				indices = append(indices, index)
+			}
+		}
+
+		for _, index := range indices {
+			if ev.permitsField(v.Type(), index) {
+				n += zero(fieldByIndex(v, index, false))
+			}
+		}
+
+	case reflect.Map:
+		if seg.tag == "" {
+			break
+		}
+		if seg.tagAny {
+			return deleteEntries(v, v.MapKeys())
+		}
+		if key := wireKey(seg.selection, v.Type().Key()); key.IsValid() {
+			return deleteEntries(v, []reflect.Value{key})
+		}
+
+	case reflect.Array, reflect.Slice:
+		if seg.tag == "" {
+			break
+		}
+		var indices []int
+		if seg.tagAny {
+			for i, n := 0, v.Len(); i < n; i++ {
+				indices = append(indices, i)
+			}
+		} else if i, ok := wireIndex(seg.selection); ok && i < v.Len() {
+			indices = append(indices, i)
+		}
+		return deleteElements(v, indices)
+
+	}
+	return n
+}
+
+// deleteKeys removes the key selection of seg from v, unless the budget of ev
+// runs out.
+func deleteKeys(v reflect.Value, seg *segment, ev *evaluation) int {
+	v = follow(v, false)
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		indices := seg.elementIndices(v, ev)
+		if ev.voided() {
+			return 0
+		}
+		return deleteElements(v, indices)
+	case reflect.Map:
+		keys := seg.matchKeys(v, ev)
+		if ev.voided() {
+			return 0
+		}
+		return deleteEntries(v, keys)
+	}
+	return 0
+}
+
+// deleteEntries removes keys from map m.
+func deleteEntries(m reflect.Value, keys []reflect.Value) (n int) {
+	if !m.CanInterface() {
+		return 0
+	}
+	for _, key := range keys {
+		if m.MapIndex(key).IsValid() {
+			m.SetMapIndex(key, reflect.Value{})
+			n++
+		}
+	}
+	return n
+}
+
+// deleteElements removes the element numbers from v. Slices shrink, while
+// array elements are set to their zero value instead.
+func deleteElements(v reflect.Value, indices []int) (n int) {
+	if v.Kind() == reflect.Array {
+		for _, i := range indices {
+			n += zero(v.Index(i))
+		}
+		return n
+	}
+
+	if !v.CanSet() || len(indices) == 0 {
+		return 0
+	}
+	remove := make([]bool, v.Len())
+	for _, i := range indices {
+		if !remove[i] {
+			remove[i] = true
+			n++
+		}
+	}
+
+	// compact in place to preserve order
+	writeIndex := 0
+	for i, drop := range remove {
+		if drop {
+			continue
+		}
+		if i != writeIndex {
+			v.Index(writeIndex).Set(v.Index(i))
+		}
+		writeIndex++
+	}
+	// release references for the garbage collector
+	for i := writeIndex; i < len(remove); i++ {
+		v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+	}
+	v.SetLen(writeIndex)
+	return n
+}
+
+// zero sets v to the zero value, and it returns the number of modifications.
+func zero(v reflect.Value) int {
+	if !v.IsValid() || !v.CanSet() || v.IsZero() {
+		return 0
+	}
+	v.Set(reflect.Zero(v.Type()))
+	return 1
+}
+
+// Append adds values to the end of each slice at the path on root, and it
+// returns the number of slices extended. All content in the path, including
+// the slices, is instantiated on the fly like with Assign. The values must be
+// assignable, or convertible, to the element type. Nil values apply as zero
+// for pointer, interface, map, slice, channel and function types.
+func Append(root interface{}, path string, values ...interface{}) (n int) {
+	ev := &evaluation{build: true}
+	return appendValues(eval(path, root, ev), ev, values)
+}
+
+// Append is like the package-level function with the same name.
+func (x *Expr) Append(root interface{}, values ...interface{}) (n int) {
+	ev := &evaluation{build: true}
+	return appendValues(x.eval(root, ev), ev, values)
+}
+
+// Insert puts value at index in each slice at the path on root, and it returns
+// the number of slices extended. The index must be in range of zero up to and
+// including the length of a slice. Elements from the index onwards shift one
+// position. Instantiation and type flexibility follow the rules of Append.
+func Insert(root interface{}, path string, index int, value interface{}) (n int) {
+	ev := &evaluation{build: true}
+	return insertValue(eval(path, root, ev), ev, index, value)
+}
+
+// Insert is like the package-level function with the same name.
+func (x *Expr) Insert(root interface{}, index int, value interface{}) (n int) {
+	ev := &evaluation{build: true}
+	return insertValue(x.eval(root, ev), ev, index, value)
+}
+
+// appendValues adds values to each slice in targets.
+func appendValues(targets []reflect.Value, ev *evaluation, values []interface{}) (n int) {
+	for _, v := range targets {
+		if a, ok := elementValues(v, values); ok {
+			v.Set(reflect.Append(v, a...))
+			n++
+		}
+	}
+
+	ev.finish()
+	return n
+}
+
+// insertValue puts value at index in each slice in targets.
+func insertValue(targets []reflect.Value, ev *evaluation, index int, value interface{}) (n int) {
+	for _, v := range targets {
+		a, ok := elementValues(v, []interface{}{value})
+		if !ok || index < 0 || index > v.Len() {
+			continue
+		}
+		v.Set(reflect.Append(v, a[0]))
+		reflect.Copy(v.Slice(index+1, v.Len()), v.Slice(index, v.Len()-1))
+		v.Index(index).Set(a[0])
+		n++
+	}
+
+	ev.finish()
+	return n
+}
+
+// elementValues returns values for the element type of v, given v is a settable
+// slice and all values fit.
+func elementValues(v reflect.Value, values []interface{}) ([]reflect.Value, bool) {
+	if v.Kind() != reflect.Slice || !v.CanSet() || len(values) == 0 {
+		return nil, false
+	}
+	t := v.Type().Elem()
+
+	a := make([]reflect.Value, len(values))
+	for i, value := range values {
+		w := reflect.ValueOf(value)
+		switch {
+		case !w.IsValid():
+			switch t.Kind() {
+			case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+				w = reflect.Zero(t)
+			default:
+				return nil, false
+			}
+		case w.Type().AssignableTo(t):
+			break
+		case w.Type().ConvertibleTo(t):
+			w = w.Convert(t)
+		default:
+			return nil, false
+		}
+		a[i] = w
+	}
+	return a, true
+}
@@ -0,0 +1,222 @@
+package el
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+type Session struct {
+	User    string
+	Expired bool
+	Tags    []string
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+		// content after deletion
+		sessions []*Session
+		index    map[string]*Session
+		labels   map[string]string
+	}{
+		{"/Sessions[1]", 1, []*Session{{User: "a"}, {User: "c", Expired: true}}, nil, nil},
+		{"/Sessions[-1]", 1, []*Session{{User: "a"}, {User: "b", Expired: true}}, nil, nil},
+		{"/Sessions[3]", 0, nil, nil, nil},
+		{"/Sessions[?Expired]", 2, []*Session{{User: "a"}}, nil, nil},
+		{"/Sessions[::2]", 2, []*Session{{User: "b", Expired: true}}, nil, nil},
+		{"/Sessions[*]", 3, []*Session{}, nil, nil},
+		{"/Sessions[*]/User", 3, []*Session{{}, {Expired: true}, {Expired: true}}, nil, nil},
+		{`/Index["x"]`, 1, nil, map[string]*Session{"y": {User: "y"}}, nil},
+		{`/Index["z"]`, 0, nil, nil, nil},
+		{`/Index[*]/User`, 2, nil, map[string]*Session{"x": {}, "y": {}}, nil},
+		{`/Labels[?. == "v"]`, 1, nil, nil, map[string]string{"w": "w"}},
+		{`/@json:labels/@json:w`, 1, nil, nil, map[string]string{"v": "v"}},
+	}
+
+	for _, test := range tests {
+		root := &struct {
+			Sessions []*Session
+			Index    map[string]*Session
+			Labels   map[string]string `json:"labels"`
+		}{
+			Sessions: []*Session{{User: "a"}, {User: "b", Expired: true}, {User: "c", Expired: true}},
+			Index:    map[string]*Session{"x": {User: "x"}, "y": {User: "y"}},
+			Labels:   map[string]string{"v": "v", "w": "w"},
+		}
+		sessions, index, labels := test.sessions, test.index, test.labels
+		if sessions == nil {
+			sessions = root.Sessions
+		}
+		if index == nil {
+			index = root.Index
+		}
+		if labels == nil {
+			labels = root.Labels
+		}
+		sessions = append([]*Session(nil), sessions...)
+
+		if got := Delete(root, test.path); got != test.want {
+			t.Errorf("%s: got %d removals, want %d", test.path, got, test.want)
+		}
+		verify.Values(t, test.path+" sessions", root.Sessions, sessions)
+		verify.Values(t, test.path+" index", root.Index, index)
+		verify.Values(t, test.path+" labels", root.Labels, labels)
+	}
+}
+
+func TestDeleteZero(t *testing.T) {
+	x := &struct {
+		P *Session
+		I interface{}
+		A [3]int
+		S Session
+		M map[string]Session
+	}{
+		P: &Session{User: "p"},
+		I: 42,
+		A: [3]int{1, 2, 3},
+		S: Session{User: "s", Tags: []string{"a", "b"}},
+		M: map[string]Session{"k": {User: "k", Tags: []string{"a", "b", "c"}}},
+	}
+
+	if n := Delete(x, "/P"); n != 1 {
+		t.Errorf("got %d removals for pointer, want 1", n)
+	}
+	if n := Delete(x, "/P"); n != 0 {
+		t.Errorf("got %d removals for nil pointer, want 0", n)
+	}
+	if n := Delete(x, "/I"); n != 1 {
+		t.Errorf("got %d removals for interface, want 1", n)
+	}
+	if n := Delete(x, "/A[1:]"); n != 2 {
+		t.Errorf("got %d removals for array, want 2", n)
+	}
+	if n := Delete(x, "/S/Tags[0]"); n != 1 {
+		t.Errorf("got %d removals for nested slice, want 1", n)
+	}
+	if n := Delete(x, `/M["k"]/Tags[1]`); n != 1 {
+		t.Errorf("got %d removals in map value, want 1", n)
+	}
+	if n := Delete(x, "/P/User"); n != 0 {
+		t.Errorf("got %d removals through nil pointer, want 0", n)
+	}
+
+	verify.Values(t, "pointer", x.P, (*Session)(nil))
+	verify.Values(t, "interface", x.I, nil)
+	verify.Values(t, "array", x.A, [3]int{1, 0, 0})
+	verify.Values(t, "nested slice", x.S.Tags, []string{"b"})
+	verify.Values(t, "map value", x.M["k"].Tags, []string{"a", "c"})
+}
+
+func TestDeleteNoConstruction(t *testing.T) {
+	x := &struct {
+		P *Session
+		M map[string]Session
+	}{M: map[string]Session{}}
+
+	if n := Delete(x, "/P/Tags[0]"); n != 0 {
+		t.Errorf("got %d removals, want 0", n)
+	}
+	if n := Delete(x, `/M["k"]/User`); n != 0 {
+		t.Errorf("got %d removals, want 0", n)
+	}
+	if x.P != nil || len(x.M) != 0 {
+		t.Errorf("constructed content: %+v", x)
+	}
+}
+
+func TestDeleteCompiled(t *testing.T) {
+	x := MustCompile("/.[?. < 0]")
+	a := []int{-1, 2, -3, 4}
+	if n := x.Delete(&a); n != 2 {
+		t.Errorf("got %d removals, want 2", n)
+	}
+	verify.Values(t, "slice", a, []int{2, 4})
+
+	if n := x.Delete(a); n != 0 {
+		t.Errorf("got %d removals on non-pointer, want 0", n)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	x := new(struct {
+		Tags  []string
+		Nums  []int64
+		Ptrs  []*Session
+		Index map[string][]int
+		Child *struct{ Tags []string }
+		Array [2]int
+	})
+
+	if n := Append(x, "/Tags", "a", "b"); n != 1 {
+		t.Errorf("got %d extended on nil slice, want 1", n)
+	}
+	if n := Append(x, "/Tags", "c"); n != 1 {
+		t.Errorf("got %d extended, want 1", n)
+	}
+	if n := Append(x, "/Nums", 1, uint8(2), 3.0); n != 1 {
+		t.Errorf("got %d extended with conversion, want 1", n)
+	}
+	if n := Append(x, "/Nums", 4, "5"); n != 0 {
+		t.Errorf("got %d extended with mismatch, want 0", n)
+	}
+	if n := Append(x, "/Ptrs", nil, &Session{User: "u"}); n != 1 {
+		t.Errorf("got %d extended with pointers, want 1", n)
+	}
+	if n := Append(x, `/Index["k"]`, 7); n != 1 {
+		t.Errorf("got %d extended in map, want 1", n)
+	}
+	if n := Append(x, "/Child/Tags", "d"); n != 1 {
+		t.Errorf("got %d extended through nil pointer, want 1", n)
+	}
+	if n := Append(x, "/Array", 1); n != 0 {
+		t.Errorf("got %d extended on array, want 0", n)
+	}
+	if n := Append(x, "/Tags"); n != 0 {
+		t.Errorf("got %d extended without values, want 0", n)
+	}
+
+	verify.Values(t, "tags", x.Tags, []string{"a", "b", "c"})
+	verify.Values(t, "nums", x.Nums, []int64{1, 2, 3})
+	verify.Values(t, "ptrs", x.Ptrs, []*Session{nil, {User: "u"}})
+	verify.Values(t, "map", x.Index, map[string][]int{"k": {7}})
+	verify.Values(t, "child", x.Child.Tags, []string{"d"})
+}
+
+func TestInsert(t *testing.T) {
+	x := &struct {
+		Sessions []Session
+	}{
+		Sessions: []Session{{Tags: []string{"b"}}, {Tags: []string{}}, {}},
+	}
+
+	if n := Insert(x, "/Sessions[*]/Tags", 0, "a"); n != 3 {
+		t.Errorf("got %d extended with wildcard, want 3", n)
+	}
+	if n := Insert(x, "/Sessions[0]/Tags", 2, "c"); n != 1 {
+		t.Errorf("got %d extended at end, want 1", n)
+	}
+	if n := Insert(x, "/Sessions[0]/Tags", 1, "x"); n != 1 {
+		t.Errorf("got %d extended in middle, want 1", n)
+	}
+	for _, index := range []int{-1, 5} {
+		if n := Insert(x, "/Sessions[0]/Tags", index, "?"); n != 0 {
+			t.Errorf("got %d extended at index %d, want 0", n, index)
+		}
+	}
+
+	verify.Values(t, "first", x.Sessions[0].Tags, []string{"a", "x", "b", "c"})
+	verify.Values(t, "second", x.Sessions[1].Tags, []string{"a"})
+	verify.Values(t, "third", x.Sessions[2].Tags, []string{"a"})
+
+	compiled := MustCompile("/Sessions")
+	if n := compiled.Insert(x, 1, Session{User: "new"}); n != 1 {
+		t.Errorf("got %d extended compiled, want 1", n)
+	}
+	if n := compiled.Append(x, Session{User: "last"}); n != 1 {
+		t.Errorf("got %d extended compiled, want 1", n)
+	}
+	verify.Values(t, "users", Strings("/Sessions[*]/User", x), []string{"", "new", "", "", "last"})
+}
@@ -1,22 +1,124 @@
 // Package el implements expression language "GoEL".
 //
 // The API is error-free by design. Malformed expressions simply have no result.
+// Explain reports the reasons in detail.
 //
 // Slash-separated paths specify content for lookups or modification. All paths
 // are subjected to normalization rules. See http://golang.org/pkg/path#Clean
 //
 //	path            ::= path-component | path path-component
 //	path-component  ::= "/" segment
-//	segment         ::= "" | ".." | selection | selection key
-//	selection       ::= "." | go-field-name
+//	segment         ::= "" | ".." | "**" | selection | selection key
+//	selection       ::= "." | go-field-name | "@" tag-key ":" tag-name |
+//	                    go-method-name "(" [ go-literal { "," go-literal } ] ")"
 //	key             ::= "[" key-selection "]"
-//	key-selection   ::= "*" | go-literal
+//	key-selection   ::= "*" | go-literal | "?" filter
 //
-// Both exported and non-exported struct fields can be selected by name.
+// Both exported and non-exported struct fields can be selected by name, unless
+// a Policy denies them.
+//
+// The "@" notation selects fields by their name in a struct tag instead, like
+// "@json:cache_ttl" or "@yaml:ttl". Options after a comma are ignored, and so
+// are fields tagged "-". Fields without the tag go by their Go name. Promotion
+// from embedded structs follows the rules of encoding/json, which includes the
+// restriction to exported fields. Names with a slash or a square bracket need
+// quotes, as in `@json:"I/O"`. Conform the wire representation, the notation
+// also selects map entries by their key, and elements of arrays and slices by
+// their index, both in decimal notation. The asterisk, as in "@json:*", is a
+// wildcard for all of the above. RFC 6901 JSON Pointers and JSONPaths convert
+// into this notation with FromJSONPointer and FromJSONPath respectively.
+//
+// Exported methods are invoked by their name with parenthesis, as in
+// "/URL/Hostname()" or `/Started/Format("2006-01-02")`. Arguments are literals
+// which convert to the respective parameter type. Methods with a pointer
+// receiver apply to addressable content only. The method must return a single
+// value, other than an error, optionally followed by an error. A non-nil error
+// counts as no result, and so does a panic. Results of invocations can not be
+// modified. Lookups run the methods as is, including any side effects they
+// have. Expressions compiled with Limits have no invocations, unless their
+// AllowCalls says otherwise.
 //
 // Elements in indexed types array, slice and string are denoted with a zero
 // based number inbetween square brackets. Key selections from map types also
 // use the square bracket notation. Asterisk is treated as a wildcard.
+//
+// Element selections also accept negative numbers, which count from the end,
+// and ranges with a start, an (exclusive) end and a step, separated by colons.
+// Each part is optional, as in "[-1]", "[2:5]", "[:3]" or "[::2]". A negative
+// step reverses the order. Assign grows slices to the end of a range, just like
+// it does for an index.
+//
+// Double asterisk is a recursive descent. It selects the content at any depth,
+// including the current node itself. Pointers which were seen before are not
+// followed again, such that reference cycles terminate.
+//
+//	/Services/**/Timeout
+//
+// Filters select the elements, or the map values, for which a predicate holds.
+// Paths in a filter are relative to the element, without the leading slash,
+// and "." denotes the element itself. Comparison operators "==", "!=", "<",
+// "<=", ">" and ">=" apply to numbers, strings and booleans. Paths with more
+// than one value match when any of them does. Conditions combine with "&&",
+// "||" and parenthesis. A path on its own holds when it has a value, other
+// than boolean false.
+//
+//	/Nodes[?Name == "db"]/Cache/TTL
+//	/Orders[?Total > 100 && Lines[*]/SKU == "X-1"]
+//
+// Filters accept the full expression syntax, as described next, with their
+// paths relative to the element.
+//
+// Expressions combine paths with literals, operators and functions. Paths are
+// absolute there, and operators other than comparisons and logical ones need
+// white space after a path, as their characters are valid in path components.
+// Arithmetic applies to numbers with one value each, and "+" also concatenates
+// strings. Integer operands produce an integer result, with none on overflow.
+// The ternary "?:" selects an operand on the truth of a condition. Functions
+// len, count, sum, min, max, contains, matches and lower are available.
+// Modifications apply to paths only.
+//
+//	len(/Nodes[*]) > 3 && /Cache/TTL >= 60
+//	/Retry/Count * /Retry/Delay / 1000
+//	matches(lower(/Name), "^db-[0-9]+$") ? /Primary : /Replica
+//
+// Functions:
+//
+//	len(x)         length of a string, an array, a slice or a map, or
+//	               the number of values for paths with a wildcard, a
+//	               recursive descent, a filter or a range
+//	count(x)       number of values
+//	sum(x)         total of the numbers
+//	min(x, ...)    lowest value
+//	max(x, ...)    highest value
+//	contains(x, y) substring, or any value (or element) equals y
+//	matches(s, re) regular expression match, conform package regexp
+//	lower(s)       string in lower case
+//
+// The package-level functions parse their expression on each invocation.
+// Compile prepares an Expr for repeated use instead.
+//
+// Get and GetAll return results of any type, including named types, structs
+// and interfaces. The functions per kind, like Int and Strings, normalize to
+// their widest type.
+//
+// MergePatch and ApplyPatch apply RFC 7386 JSON Merge Patches and RFC 6902
+// JSON Patches onto typed content, with each patch as a whole or not at all.
+// Diff expresses the changes between two values as operations on canonical
+// paths. Apply replays them, and they marshal as a JSON Patch.
+//
+// Redact returns a deep copy with the content at paths blanked, which leaves
+// the original intact, e.g., for logging. Project does the opposite with a
+// deep copy of only the content at paths, and ProjectMap returns the same as a
+// tree of maps with JSON names, e.g., for partial responses. ProjectMapExpr
+// does the same with compiled paths, which keeps their limits and policy.
+//
+// Expressions from untrusted sources compile with Limits, which bounds their
+// size and the resources of each evaluation. A Policy restricts the content
+// available on top of that, by field visibility, by struct tag and by path.
+//
+// Check verifies an expression against a type without the need for a value, and
+// Paths lists the options available. CheckTarget verifies a single settable
+// target for Assign.
 package el
 
 import (
@@ -28,17 +130,119 @@ type finisher interface {
 	Finish()
 }
 
-func eval(expr string, root interface{}, buildCallbacks *[]finisher) []reflect.Value {
-	if expr == "" {
-		return nil
+// evaluation is the state of a modifying resolve. A nil evaluation is read-only,
+// and so is a query.
+type evaluation struct {
+	// build enables instantiation of absent content.
+	build bool
+	// callbacks have the post modification requirements.
+	callbacks []finisher
+
+	// query marks a read-only evaluation, which exists for its budget.
+	query bool
+	// budget has the resource consumption, if limited.
+	budget *budget
+	// policy restricts field selections, if any.
+	policy *Policy
+
+	// trace enables the record of hops and reasons, for Locate and Explain.
+	trace bool
+	// hops has the location of each value in the result of a selection.
+	hops []hop
+	// reasons has an explanation per value in the track of a selection
+	// which had no result, where available.
+	reasons map[int]string
+}
+
+// modifies returns whether the evaluation is for modification.
+func (ev *evaluation) modifies() bool {
+	return ev != nil && !ev.query
+}
+
+// inspects returns whether the evaluation leaves all content as is, which is
+// the case for filters, Explain and lookups with limits or a policy. Lookups
+// without an evaluation grow settable slices for an index beyond the length.
+func (ev *evaluation) inspects() bool {
+	return ev != nil && !ev.build
+}
+
+// builds returns whether absent content should be instantiated.
+func (ev *evaluation) builds() bool {
+	return ev != nil && ev.build
+}
+
+// finish applies the post modification requirements.
+func (ev *evaluation) finish() {
+	for _, c := range ev.callbacks {
+		c.Finish()
 	}
+	ev.callbacks = nil
+}
 
-	switch expr[0] {
-	case '/':
-		return resolve(expr, root, buildCallbacks)
-	default:
+func eval(expr string, root interface{}, ev *evaluation) []reflect.Value {
+	if isPath(expr) {
+		path, err := parsePath(expr)
+		if err != nil {
+			return nil
+		}
+		return resolve(path, root, ev)
+	}
+
+	if ev.modifies() {
+		return nil // modification applies to paths only
+	}
+	x, err := parseOperation("expression", expr)
+	if err != nil {
 		return nil
 	}
+	return x.eval(reflect.ValueOf(root), ev)
+}
+
+// isPath returns whether expr is a path, as opposed to an expression with
+// operators or functions. Paths have no white space, no parenthesis and no
+// comparison or logical operators outside key selections, method invocations
+// and quoted literals.
+func isPath(expr string) bool {
+	if expr == "" || expr[0] != '/' {
+		return false
+	}
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case ' ', '\t', ')', '<', '>':
+			return false
+		case '=', '!', '&', '|':
+			if i+1 < len(expr) && (expr[i+1] == '=' || expr[i+1] == expr[i]) {
+				return false
+			}
+		case '(':
+			if !isNameChar(expr[i-1]) {
+				return false
+			}
+			end, err := parenEnd(expr, i)
+			if err != nil {
+				return true // malformed path
+			}
+			i = end - 1
+		case '[':
+			end, err := bracketEnd(expr, i)
+			if err != nil {
+				return true // malformed path
+			}
+			i = end - 1
+		case '"', '`':
+			end, err := literalEnd(expr, i)
+			if err != nil {
+				return true // malformed path
+			}
+			i = end - 1
+		}
+	}
+	return true
+}
+
+// isNameChar returns whether c is valid in an ASCII identifier.
+func isNameChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
 }
 
 // Assign applies want to the path on root and returns the number of successes.
@@ -51,10 +255,12 @@ func eval(expr string, root interface{}, buildCallbacks *[]finisher) []reflect.V
 // In short, root should be a pointer and the destination should be exported.
 // See http://blog.golang.org/laws-of-reflection#TOC_8%2E
 func Assign(root interface{}, path string, want interface{}) (n int) {
-	var buildCallbacks []finisher
-
-	values := eval(path, root, &buildCallbacks)
+	ev := &evaluation{build: true}
+	return assign(eval(path, root, ev), ev, want)
+}
 
+// assign applies want to each of the values.
+func assign(values []reflect.Value, ev *evaluation, want interface{}) (n int) {
 	w := follow(reflect.ValueOf(want), false)
 	if !w.IsValid() {
 		return
@@ -76,17 +282,17 @@ func Assign(root interface{}, path string, want interface{}) (n int) {
 		}
 	}
 
-	for _, c := range buildCallbacks {
-		c.Finish()
-	}
-
+	ev.finish()
 	return n
 }
 
 // Bool returns the evaluation result if, and only if, the result has one value
 // and the value is a boolean type.
 func Bool(expr string, root interface{}) (result bool, ok bool) {
-	a := eval(expr, root, nil)
+	return boolResult(eval(expr, root, nil))
+}
+
+func boolResult(a []reflect.Value) (result bool, ok bool) {
 	if len(a) == 1 {
 		v := a[0]
 		if v.Kind() == reflect.Bool {
@@ -99,7 +305,10 @@ func Bool(expr string, root interface{}) (result bool, ok bool) {
 // Int returns the evaluation result if, and only if, the result has one value
 // and the value is an integer type.
 func Int(expr string, root interface{}) (result int64, ok bool) {
-	a := eval(expr, root, nil)
+	return intResult(eval(expr, root, nil))
+}
+
+func intResult(a []reflect.Value) (result int64, ok bool) {
 	if len(a) == 1 {
 		v := a[0]
 		switch v.Kind() {
@@ -113,7 +322,10 @@ func Int(expr string, root interface{}) (result int64, ok bool) {
 // Uint returns the evaluation result if, and only if, the result has one value
 // and the value is an unsigned integer type.
 func Uint(expr string, root interface{}) (result uint64, ok bool) {
-	a := eval(expr, root, nil)
+	return uintResult(eval(expr, root, nil))
+}
+
+func uintResult(a []reflect.Value) (result uint64, ok bool) {
 	if len(a) == 1 {
 		v := a[0]
 		switch v.Kind() {
@@ -127,7 +339,10 @@ func Uint(expr string, root interface{}) (result uint64, ok bool) {
 // Float returns the evaluation result if, and only if, the result has one value
 // and the value is a floating point type.
 func Float(expr string, root interface{}) (result float64, ok bool) {
-	a := eval(expr, root, nil)
+	return floatResult(eval(expr, root, nil))
+}
+
+func floatResult(a []reflect.Value) (result float64, ok bool) {
 	if len(a) == 1 {
 		v := a[0]
 		switch v.Kind() {
@@ -141,7 +356,10 @@ func Float(expr string, root interface{}) (result float64, ok bool) {
 // Complex returns the evaluation result if, and only if, the result has one
 // value and the value is a complex type.
 func Complex(expr string, root interface{}) (result complex128, ok bool) {
-	a := eval(expr, root, nil)
+	return complexResult(eval(expr, root, nil))
+}
+
+func complexResult(a []reflect.Value) (result complex128, ok bool) {
 	if len(a) == 1 {
 		v := a[0]
 		switch v.Kind() {
@@ -155,7 +373,10 @@ func Complex(expr string, root interface{}) (result complex128, ok bool) {
 // String returns the evaluation result if, and only if, the result has one
 // value and the value is a string type.
 func String(expr string, root interface{}) (result string, ok bool) {
-	a := eval(expr, root, nil)
+	return stringResult(eval(expr, root, nil))
+}
+
+func stringResult(a []reflect.Value) (result string, ok bool) {
 	if len(a) == 1 {
 		v := a[0]
 		if v.Kind() == reflect.String {
@@ -167,7 +388,10 @@ func String(expr string, root interface{}) (result string, ok bool) {
 
 // Any returns the evaluation result values.
 func Any(expr string, root interface{}) []interface{} {
-	a := eval(expr, root, nil)
+	return anyResult(eval(expr, root, nil))
+}
+
+func anyResult(a []reflect.Value) []interface{} {
 	if len(a) == 0 {
 		return nil
 	}
@@ -183,7 +407,10 @@ func Any(expr string, root interface{}) []interface{} {
 
 // Bools returns the evaluation result values of a boolean type.
 func Bools(expr string, root interface{}) []bool {
-	a := eval(expr, root, nil)
+	return boolsResult(eval(expr, root, nil))
+}
+
+func boolsResult(a []reflect.Value) []bool {
 	if len(a) == 0 {
 		return nil
 	}
@@ -199,7 +426,10 @@ func Bools(expr string, root interface{}) []bool {
 
 // Ints returns the evaluation result values of an integer type.
 func Ints(expr string, root interface{}) []int64 {
-	a := eval(expr, root, nil)
+	return intsResult(eval(expr, root, nil))
+}
+
+func intsResult(a []reflect.Value) []int64 {
 	if len(a) == 0 {
 		return nil
 	}
@@ -216,7 +446,10 @@ func Ints(expr string, root interface{}) []int64 {
 
 // Uints returns the evaluation result values of an unsigned integer type.
 func Uints(expr string, root interface{}) []uint64 {
-	a := eval(expr, root, nil)
+	return uintsResult(eval(expr, root, nil))
+}
+
+func uintsResult(a []reflect.Value) []uint64 {
 	if len(a) == 0 {
 		return nil
 	}
@@ -233,7 +466,10 @@ func Uints(expr string, root interface{}) []uint64 {
 
 // Floats returns the evaluation result values of a floating point type.
 func Floats(expr string, root interface{}) []float64 {
-	a := eval(expr, root, nil)
+	return floatsResult(eval(expr, root, nil))
+}
+
+func floatsResult(a []reflect.Value) []float64 {
 	if len(a) == 0 {
 		return nil
 	}
@@ -250,7 +486,10 @@ func Floats(expr string, root interface{}) []float64 {
 
 // Complexes returns the evaluation result values of a complex type.
 func Complexes(expr string, root interface{}) []complex128 {
-	a := eval(expr, root, nil)
+	return complexesResult(eval(expr, root, nil))
+}
+
+func complexesResult(a []reflect.Value) []complex128 {
 	if len(a) == 0 {
 		return nil
 	}
@@ -267,7 +506,10 @@ func Complexes(expr string, root interface{}) []complex128 {
 
 // Strings returns the evaluation result values of a string type.
 func Strings(expr string, root interface{}) []string {
-	a := eval(expr, root, nil)
+	return stringsResult(eval(expr, root, nil))
+}
+
+func stringsResult(a []reflect.Value) []string {
 	if len(a) == 0 {
 		return nil
 	}
@@ -0,0 +1,129 @@
+package el
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// elementRange is a key selection on indexed types array, slice and string.
+// Negative numbers count from the end. Absent bounds default to the start and
+// the end respectively, reversed when step is negative.
+type elementRange struct {
+	// single is set for a (negative) index, rather than a range.
+	single bool
+
+	start, end, step int
+	hasStart, hasEnd bool
+}
+
+// parseRange returns the element range notation of s, if any.
+//
+//	range ::= integer | [ integer ] ":" [ integer ] [ ":" [ integer ] ]
+func parseRange(s string) (r *elementRange, ok bool, err error) {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte("0123456789abcdefABCDEFoOxX_+-:", s[i]) < 0 {
+			return nil, false, nil // other literal
+		}
+	}
+
+	if !strings.ContainsRune(s, ':') {
+		// only negative indices remain
+		if s[0] != '-' {
+			return nil, false, nil
+		}
+		i, err := strconv.ParseInt(s, 0, 64)
+		if err != nil || i < -(1<<31) {
+			return nil, false, nil
+		}
+		return &elementRange{single: true, start: int(i), hasStart: true}, true, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return nil, false, fmt.Errorf("goe el: element range [%s] has more than 3 parts", s)
+	}
+	r = &elementRange{step: 1}
+	for i, p := range parts {
+		if p == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(p, 0, 64)
+		if err != nil || n <= -(1<<31) || n >= 1<<31 {
+			return nil, false, fmt.Errorf("goe el: element range [%s] has malformed number %q", s, p)
+		}
+		switch i {
+		case 0:
+			r.start, r.hasStart = int(n), true
+		case 1:
+			r.end, r.hasEnd = int(n), true
+		case 2:
+			if n == 0 {
+				return nil, false, fmt.Errorf("goe el: element range [%s] has zero step", s)
+			}
+			r.step = int(n)
+		}
+	}
+	return r, true, nil
+}
+
+// bounds returns the first element number and the limit (exclusive) for an
+// iteration on length n with step.
+func (r *elementRange) bounds(n int) (start, end int) {
+	if r.step > 0 {
+		start, end = 0, n
+		if r.hasStart {
+			start = clampBound(r.start, n, 0, n)
+		}
+		if r.hasEnd {
+			end = clampBound(r.end, n, 0, n)
+		}
+	} else {
+		start, end = n-1, -1
+		if r.hasStart {
+			start = clampBound(r.start, n, -1, n-1)
+		}
+		if r.hasEnd {
+			end = clampBound(r.end, n, -1, n-1)
+		}
+	}
+	return
+}
+
+// clampBound returns i for length n in the range of min to max.
+func clampBound(i, n, min, max int) int {
+	if i < 0 {
+		i += n
+	}
+	switch {
+	case i < min:
+		return min
+	case i > max:
+		return max
+	}
+	return i
+}
+
+// indices returns the element numbers in the range for length n, in order of
+// iteration.
+func (r *elementRange) indices(n int) []int {
+	if r.single {
+		if i := r.start + n; i >= 0 {
+			return []int{i}
+		}
+		return nil
+	}
+
+	var a []int
+	start, end := r.bounds(n)
+	if r.step > 0 {
+		for i := start; i < end; i += r.step {
+			a = append(a, i)
+		}
+	} else {
+		for i := start; i > end; i += r.step {
+			a = append(a, i)
+		}
+	}
+	return a
+}
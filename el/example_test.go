@@ -1,9 +1,13 @@
 package el_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"image/gif"
+	"net/url"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/pascaldekloe/goe/el"
 )
@@ -45,3 +49,337 @@ func ExampleUints() {
 	fmt.Printf("RGBA: %v", el.Uints("/Palette[0]/*", img))
 	// Output: RGBA: [255 255 255 255]
 }
+
+func ExampleCompile() {
+	type server struct {
+		Name string
+		Port uint16
+	}
+	servers := []*server{{"a", 80}, {"b", 443}}
+
+	port := el.MustCompile("/Port")
+	for _, s := range servers {
+		n, _ := port.Uint(s)
+		fmt.Println(s.Name, n)
+	}
+	// Output:
+	// a 80
+	// b 443
+}
+
+func ExampleExplain() {
+	type cache struct{ TTL int }
+	type node struct{ Cache *cache }
+	x := struct{ Nodes []node }{Nodes: make([]node, 8)}
+
+	fmt.Println(el.Explain("/Nodes[7]/Cache/TTL", x))
+	// Output:
+	// GoEL "/Nodes[7]/Cache/TTL" has 0 results
+	// /Nodes[7]: 1 of 1 candidates
+	// /Cache: 1 of 1 candidates
+	// /TTL: 0 of 1 candidates
+	// 	nil *el_test.cache
+}
+
+func ExampleAssign_filter() {
+	type node struct {
+		Name string
+		TTL  int
+	}
+	x := &struct{ Nodes []node }{
+		Nodes: []node{{"db", 60}, {"web", 60}, {"db", 90}},
+	}
+
+	n := el.Assign(x, `/Nodes[?Name == "db" && TTL < 90]/TTL`, 3600)
+
+	fmt.Println(n, x.Nodes)
+	// Output: 1 [{db 3600} {web 60} {db 90}]
+}
+
+func ExampleDelete() {
+	type session struct {
+		User    string
+		Expired bool
+	}
+	x := &struct{ Sessions []session }{
+		Sessions: []session{{"a", true}, {"b", false}, {"c", true}},
+	}
+
+	n := el.Delete(x, "/Sessions[?Expired]")
+
+	fmt.Println(n, x.Sessions)
+	// Output: 2 [{b false}]
+}
+
+func ExampleInsert() {
+	x := &struct{ Path []string }{}
+	el.Append(x, "/Path", "usr", "bin")
+	el.Insert(x, "/Path", 1, "local")
+
+	fmt.Println(x.Path)
+	// Output: [usr local bin]
+}
+
+func ExampleLocate() {
+	report := map[string][]string{
+		"disk": {"full"},
+		"I/O":  {"slow", "lost"},
+	}
+
+	for _, m := range el.Locate("/.[*]/.[*]", report) {
+		fmt.Println(m.Path, m.Value)
+	}
+	// Output:
+	// /.["I\x2fO"]/.[0] slow
+	// /.["I\x2fO"]/.[1] lost
+	// /.["disk"]/.[0] full
+}
+
+func ExampleFlatten() {
+	type server struct {
+		Addr    string
+		Aliases []string
+	}
+	flat := el.Flatten(server{"localhost:80", []string{"web"}})
+	fmt.Println(flat)
+
+	var restored server
+	el.Unflatten(&restored, flat)
+	fmt.Printf("%+v\n", restored)
+	// Output:
+	// map[/Addr:localhost:80 /Aliases[0]:web]
+	// {Addr:localhost:80 Aliases:[web]}
+}
+
+func ExampleCheck() {
+	type cache struct{ TTL int }
+	type config struct{ Cache *cache }
+
+	fmt.Println(el.Check("/Cahce/TTL", reflect.TypeOf(config{})))
+	// Output: goe el: expression "/Cahce/TTL": component /Cahce has no match on type el_test.config
+}
+
+func ExampleGet() {
+	type job struct {
+		Timeout time.Duration
+		Retries uint8
+	}
+	x := &job{Timeout: 90 * time.Second, Retries: 3}
+
+	timeout, _ := el.Get[time.Duration]("/Timeout", x)
+	retries, _ := el.Get[int]("/Retries", x)
+	fmt.Println(timeout, retries)
+	// Output: 1m30s 3
+}
+
+func ExampleAssignText() {
+	type config struct {
+		Port    uint16
+		Timeout time.Duration
+		Debug   bool
+	}
+	x := new(config)
+	el.AssignText(x, "/Port", "8080")
+	el.AssignText(x, "/Timeout", "2m")
+	el.AssignText(x, "/Debug", "true")
+
+	fmt.Printf("%+v", x)
+	// Output: &{Port:8080 Timeout:2m0s Debug:true}
+}
+
+func ExampleBool_expression() {
+	type node struct {
+		Host string
+		Load int
+	}
+	x := &struct {
+		Nodes []node
+		TTL   int
+	}{
+		Nodes: []node{{"a", 90}, {"b", 20}, {"c", 85}},
+		TTL:   300,
+	}
+
+	busy, _ := el.Bool(`count(/Nodes[?Load > 80]) >= 2 && /TTL / 60 > 1`, x)
+	fmt.Println("busy:", busy)
+	fmt.Println(el.Any(`max(/Nodes[*]/Load) - min(/Nodes[*]/Load)`, x))
+	// Output:
+	// busy: true
+	// [70]
+}
+
+func ExampleString_method() {
+	x := &struct {
+		Endpoint *url.URL
+		Timeout  time.Duration
+	}{Timeout: 90 * time.Second}
+	x.Endpoint, _ = url.Parse("https://example.com:8443/api")
+
+	host, _ := el.String("/Endpoint/Hostname()", x)
+	fmt.Println("host:", host)
+	fmt.Println(el.Any("/Timeout/Minutes()", x))
+	fmt.Println(el.Strings(`/.[?Endpoint/Port() == "8443"]/Timeout/String()`, []interface{}{x}))
+	// Output:
+	// host: example.com
+	// [1.5]
+	// [1m30s]
+}
+
+func ExampleApplyPatch() {
+	type node struct {
+		Name   string            `json:"name"`
+		Ports  []int             `json:"ports"`
+		Labels map[string]string `json:"labels"`
+	}
+	x := &node{Name: "db", Ports: []int{5432}}
+
+	err := el.ApplyPatch(x, []byte(`[
+		{"op": "add", "path": "/ports/-", "value": 5433},
+		{"op": "add", "path": "/labels/env", "value": "prod"}
+	]`))
+	fmt.Printf("%+v %v\n", *x, err)
+
+	err = el.ApplyPatch(x, []byte(`[
+		{"op": "replace", "path": "/name", "value": "pg"},
+		{"op": "test", "path": "/ports/0", "value": 22}
+	]`))
+	fmt.Printf("%+v\n%v\n", *x, err)
+
+	err = el.MergePatch(x, []byte(`{"name": "pg", "labels": {"env": null}}`))
+	fmt.Printf("%+v %v\n", *x, err)
+	// Output:
+	// {Name:db Ports:[5432 5433] Labels:map[env:prod]} <nil>
+	// {Name:db Ports:[5432 5433] Labels:map[env:prod]}
+	// goe el: JSON Patch operation 1: JSON Pointer "/ports/0" has 5432, not 22
+	// {Name:pg Ports:[5432 5433] Labels:map[]} <nil>
+}
+
+func ExampleDiff() {
+	type node struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+		Ports  []int             `json:"ports"`
+	}
+	a := &node{Name: "db", Labels: map[string]string{"env": "test"}, Ports: []int{5432, 5433}}
+	b := &node{Name: "db", Labels: map[string]string{"env": "prod"}, Ports: []int{5432}}
+
+	ops := el.Diff(a, b)
+	for _, op := range ops {
+		fmt.Println(op.Op, op.Path, op.Value)
+	}
+	patch, _ := json.Marshal(ops)
+	fmt.Println(string(patch))
+
+	err := el.Apply(a, ops)
+	fmt.Printf("%+v %v\n", *a, err)
+	// Output:
+	// replace /Labels["env"] prod
+	// remove /Ports[1] <nil>
+	// [{"op":"add","path":"/labels/env","value":"prod"},{"op":"remove","path":"/ports/1"}]
+	// {Name:db Labels:map[env:prod] Ports:[5432]} <nil>
+}
+
+func ExampleRedactWith() {
+	type user struct {
+		Name     string
+		Password string
+	}
+	req := &struct {
+		Users   []user
+		Headers map[string]string
+	}{
+		Users:   []user{{"alice", "hunter2"}, {"bob", "letmein"}},
+		Headers: map[string]string{"Authorization": "Bearer x", "Accept": "*/*"},
+	}
+
+	fmt.Printf("%+v\n", el.RedactWith(req, "***", "/Users[*]/Password", `/Headers["Authorization"]`))
+	fmt.Printf("%+v\n", req)
+	// Output:
+	// &{Users:[{Name:alice Password:***} {Name:bob Password:***}] Headers:map[Accept:*/* Authorization:***]}
+	// &{Users:[{Name:alice Password:hunter2} {Name:bob Password:letmein}] Headers:map[Accept:*/* Authorization:Bearer x]}
+}
+
+func ExampleProjectMap() {
+	type address struct {
+		Street string `json:"street"`
+		City   string `json:"city"`
+	}
+	person := &struct {
+		Name    string            `json:"name"`
+		Address address           `json:"address"`
+		Phones  map[string]string `json:"phones"`
+	}{
+		Name:    "Alice",
+		Address: address{"Main", "Amsterdam"},
+		Phones:  map[string]string{"home": "1", "work": "2"},
+	}
+
+	fmt.Printf("%+v\n", el.Project(person, "/Name", "/Address/City"))
+	bytes, _ := json.Marshal(el.ProjectMap(person, "/Address/City", `/Phones["work"]`))
+	fmt.Println(string(bytes))
+	// Output:
+	// &{Name:Alice Address:{Street: City:Amsterdam} Phones:map[]}
+	// {"address":{"city":"Amsterdam"},"phones":{"work":"2"}}
+}
+
+func ExampleLimits() {
+	limits := el.Limits{MaxLength: 64, MaxDepth: 4, MaxResults: 100, MaxGrowth: 10}
+
+	_, err := limits.Compile("/A/B/C/D/E")
+	fmt.Println(err)
+
+	x, err := limits.Compile("/Items[2147483647]")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	var data struct{ Items []string }
+	fmt.Println("assigned:", x.Assign(&data, "x"), "length:", len(data.Items))
+	// Output:
+	// goe el: expression "/A/B/C/D/E" has a path depth of 5, which exceeds the maximum of 4
+	// assigned: 0 length: 0
+}
+
+func ExamplePolicy() {
+	type User struct {
+		Name     string `json:"name"`
+		Password string `json:"-"`
+		Email    string `json:"email"`
+	}
+	data := &struct {
+		Users []*User `json:"users"`
+	}{
+		Users: []*User{{Name: "alice", Password: "secret", Email: "alice@example.com"}},
+	}
+
+	policy := el.Policy{
+		Tag:       "json",
+		Read:      []string{"/Users[*]/Name", "/Users[*]/Password"},
+		ReadWrite: []string{"/Users[*]/Email"},
+	}
+	for _, expr := range []string{"/Users[*]/Name", "/Users[*]/Password"} {
+		x, err := policy.Compile(expr)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("%s: %q\n", expr, x.Strings(data))
+	}
+	all, err := el.Policy{Tag: "json"}.Compile("/Users[*]/*")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("/Users[*]/*: %q\n", all.Strings(data))
+
+	name, _ := policy.Compile("/Users[0]/Name")
+	email, _ := policy.Compile("/Users[0]/Email")
+	fmt.Println("name assigned:", name.Assign(data, "eve"))
+	fmt.Println("email assigned:", email.Assign(data, "eve@example.com"))
+	// Output:
+	// /Users[*]/Name: ["alice"]
+	// /Users[*]/Password: []
+	// /Users[*]/*: ["alice" "alice@example.com"]
+	// name assigned: 0
+	// email assigned: 1
+}
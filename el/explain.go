@@ -0,0 +1,283 @@
+package el
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Report is a diagnostic evaluation.
+type Report struct {
+	// Expr is the expression evaluated.
+	Expr string
+	// Err is set when Expr is malformed, when its policy does not permit,
+	// or when it exceeds its limits.
+	Err error
+	// Steps has the path components in order of evaluation. Evaluation
+	// stops with the step which eliminated all candidates, if any.
+	Steps []Step
+	// Results has the number of values matched.
+	Results int
+}
+
+// Step is a path component evaluation.
+type Step struct {
+	// Segment is the path component, e.g. "/Nodes[7]".
+	Segment string
+	// In is the number of candidates before, and Out is the number of
+	// candidates after, the selection.
+	In, Out int
+	// Reasons has an explanation for each candidate eliminated.
+	Reasons []string
+}
+
+// String returns a human readable summary.
+func (r *Report) String() string {
+	var buf strings.Builder
+	buf.WriteString("GoEL ")
+	buf.WriteString(fmt.Sprintf("%q", r.Expr))
+	if r.Err != nil {
+		buf.WriteString(": ")
+		buf.WriteString(r.Err.Error())
+		return buf.String()
+	}
+	fmt.Fprintf(&buf, " has %d results", r.Results)
+
+	for _, step := range r.Steps {
+		fmt.Fprintf(&buf, "\n%s: %d of %d candidates", step.Segment, step.Out, step.In)
+		for _, reason := range step.Reasons {
+			buf.WriteString("\n\t")
+			buf.WriteString(reason)
+		}
+	}
+	return buf.String()
+}
+
+// Explain evaluates expr on root like Any does, with a record of why content
+// did not match. Nothing is modified.
+func Explain(expr string, root interface{}) *Report {
+	path, err := parsePath(expr)
+	if err != nil {
+		return &Report{Expr: expr, Err: err}
+	}
+	return explain(expr, path, root, nil)
+}
+
+// Explain is like the package-level function with the same name. The limits
+// and the policy of x apply, with fields which the policy denies reported as
+// absent.
+func (x *Expr) Explain(root interface{}) *Report {
+	if x.x != nil {
+		return &Report{Expr: x.src, Err: fmt.Errorf("goe el: expression %q is not a path", x.src)}
+	}
+	if x.noRead {
+		return &Report{Expr: x.src, Err: fmt.Errorf("goe el: expression %q is not permitted by policy", x.src)}
+	}
+	ev := x.constrain(nil)
+	r := explain(x.src, x.path, root, ev)
+	if ev.voided() && ev.budget.out {
+		r.Err = fmt.Errorf("goe el: expression %q exceeds the limits", x.src)
+	}
+	return r
+}
+
+// explain reports the evaluation of path on root, with ev for the budget and
+// the policy, if any.
+func explain(expr string, path []segment, root interface{}, ev *evaluation) *Report {
+	if ev == nil {
+		ev = &evaluation{query: true}
+	}
+	ev.trace = true
+	r := &Report{Expr: expr}
+
+	v := reflect.ValueOf(root)
+	track := []reflect.Value{follow(v, false)}
+	if !track[0].IsValid() {
+		r.Steps = append(r.Steps, Step{
+			Segment: "/",
+			In:      1,
+			Reasons: []string{absentReason(v)},
+		})
+		return r
+	}
+
+	for i := range path {
+		seg := &path[i]
+		step := Step{Segment: seg.String(), In: len(track)}
+
+		var next []reflect.Value
+		if seg.descent {
+			ev.hops = nil
+			next = followDescent(track, ev)
+		} else {
+			next = track
+			if seg.selection != "" || seg.tag != "" {
+				ev.hops, ev.reasons = nil, nil
+				in := next
+				next = followField(append([]reflect.Value(nil), in...), seg, ev)
+				next = ev.sift(next, in, &step, func(v reflect.Value) string {
+					return seg.fieldReason(v, ev)
+				})
+			}
+			if seg.key != "" {
+				ev.hops, ev.reasons = nil, nil
+				in := next
+				next = followKey(append([]reflect.Value(nil), in...), seg, ev)
+				next = ev.sift(next, in, &step, seg.keyReason)
+			}
+		}
+		if !ev.spend(len(next)) {
+			next = nil
+		}
+		next = ev.leaves(next, seg)
+
+		step.Out = len(next)
+		r.Steps = append(r.Steps, step)
+		track = next
+		if len(track) == 0 {
+			return r
+		}
+	}
+
+	step := Step{Segment: "(result)", In: len(track)}
+	for _, v := range track {
+		if follow(v, false).IsValid() {
+			step.Out++
+		} else {
+			step.Reasons = append(step.Reasons, absentReason(v))
+		}
+	}
+	if len(step.Reasons) != 0 {
+		r.Steps = append(r.Steps, step)
+	}
+	r.Results = step.Out
+	return r
+}
+
+// sift returns the valid values in selection, with a reason in step for each
+// of the candidates without any. The hops of ev map selection to candidates.
+func (ev *evaluation) sift(selection, candidates []reflect.Value, step *Step, reason func(reflect.Value) string) []reflect.Value {
+	selected := make([]bool, len(candidates))
+	writeIndex := 0
+	for i, v := range selection {
+		if v.IsValid() {
+			selection[writeIndex] = v
+			ev.hops[writeIndex] = ev.hops[i]
+			selected[ev.hops[i].from] = true
+			writeIndex++
+		}
+	}
+	ev.hops = ev.hops[:writeIndex]
+
+	for i, ok := range selected {
+		if ok {
+			continue
+		}
+		if s, ok := ev.reasons[i]; ok {
+			step.Reasons = append(step.Reasons, s)
+		} else {
+			step.Reasons = append(step.Reasons, reason(candidates[i]))
+		}
+	}
+	return selection[:writeIndex]
+}
+
+// String returns the path component notation.
+func (seg *segment) String() string {
+	s := seg.selectionString()
+	if seg.key != "" {
+		s += "[" + seg.key + "]"
+	}
+	return "/" + s
+}
+
+// fieldReason explains why v has no match for the field selection. Fields
+// which the policy of ev denies are reported as absent.
+func (seg *segment) fieldReason(v reflect.Value, ev *evaluation) string {
+	f := follow(v, false)
+	var index []int
+	if f.Kind() == reflect.Struct {
+		index = seg.fieldIndex(f.Type())
+		if index != nil && !ev.permitsField(f.Type(), index) {
+			index = nil
+		}
+	}
+	switch {
+	case !f.IsValid():
+		return absentReason(v)
+	case seg.selection == "*":
+		return fmt.Sprintf("type %s has no fields", f.Type())
+	case seg.tag != "" && f.Kind() == reflect.Map:
+		return fmt.Sprintf("%s has no entry for key %q", f.Type(), seg.selection)
+	case seg.tag != "" && (f.Kind() == reflect.Array || f.Kind() == reflect.Slice):
+		return fmt.Sprintf("%s has no element %q", f.Type(), seg.selection)
+	case f.Kind() != reflect.Struct:
+		return fmt.Sprintf("type %s has no fields", f.Type())
+	case seg.tag != "" && index == nil:
+		return fmt.Sprintf("type %s has no field with %s name %q", f.Type(), seg.tag, seg.selection)
+	case index == nil:
+		return fmt.Sprintf("type %s has no field %q", f.Type(), seg.selection)
+	default:
+		return fmt.Sprintf("field %q of type %s is embedded through a nil pointer", seg.selection, f.Type())
+	}
+}
+
+// keyReason explains why v has no match for the key selection.
+func (seg *segment) keyReason(v reflect.Value) string {
+	f := follow(v, false)
+	if !f.IsValid() {
+		return absentReason(v)
+	}
+
+	switch f.Kind() {
+	case reflect.Array, reflect.Slice, reflect.String:
+		switch {
+		case seg.filter != nil:
+			return fmt.Sprintf("%s has no element matching [%s]", f.Type(), seg.key)
+		case seg.key == "*":
+			return fmt.Sprintf("%s is empty", f.Type())
+		case seg.elements != nil && seg.elements.single:
+			return fmt.Sprintf("index %s out of bounds for %s of length %d", seg.key, f.Type(), f.Len())
+		case seg.elements != nil:
+			return fmt.Sprintf("range [%s] has no elements for %s of length %d", seg.key, f.Type(), f.Len())
+		case seg.index < 0:
+			return fmt.Sprintf("key [%s] is not an index for %s", seg.key, f.Type())
+		default:
+			return fmt.Sprintf("index %d out of bounds for %s of length %d", seg.index, f.Type(), f.Len())
+		}
+
+	case reflect.Map:
+		switch {
+		case seg.filter != nil:
+			return fmt.Sprintf("%s has no entry matching [%s]", f.Type(), seg.key)
+		case seg.key == "*":
+			return fmt.Sprintf("%s is empty", f.Type())
+		case seg.mapKey(f.Type().Key()) == nil:
+			return fmt.Sprintf("key [%s] is not a literal for %s", seg.key, f.Type().Key())
+		default:
+			return fmt.Sprintf("%s has no entry for key [%s]", f.Type(), seg.key)
+		}
+
+	default:
+		return fmt.Sprintf("type %s has no keys", f.Type())
+	}
+}
+
+// absentReason explains why follow on v has no result.
+func absentReason(v reflect.Value) string {
+	for {
+		switch v.Kind() {
+		case reflect.Invalid:
+			return "no value"
+		case reflect.Ptr, reflect.Interface:
+			if v.IsNil() {
+				return fmt.Sprintf("nil %s", v.Type())
+			}
+			v = v.Elem()
+		case reflect.Map:
+			return fmt.Sprintf("nil %s", v.Type())
+		default:
+			return fmt.Sprintf("%s has no value", v.Type())
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package el
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestExplain(t *testing.T) {
+	root := &Node{
+		Name:  strptr("root"),
+		Child: &Node{S: []interface{}{"a"}},
+		X:     map[string]int{"one": 1},
+		A:     [2]interface{}{testV, nil},
+	}
+
+	tests := []struct {
+		expr string
+		want *Report
+	}{
+		{"/Name", &Report{Expr: "/Name", Results: 1, Steps: []Step{
+			{Segment: "/Name", In: 1, Out: 1},
+		}}},
+		{"/Mis", &Report{Expr: "/Mis", Steps: []Step{
+			{Segment: "/Mis", In: 1, Reasons: []string{`type el.Node has no field "Mis"`}},
+		}}},
+		{"/Child/Child/Name", &Report{Expr: "/Child/Child/Name", Steps: []Step{
+			{Segment: "/Child", In: 1, Out: 1},
+			{Segment: "/Child", In: 1, Out: 1},
+			{Segment: "/Name", In: 1, Reasons: []string{"nil *el.Node"}},
+		}}},
+		{"/Child/S[7]", &Report{Expr: "/Child/S[7]", Steps: []Step{
+			{Segment: "/Child", In: 1, Out: 1},
+			{Segment: "/S[7]", In: 1, Reasons: []string{"index 7 out of bounds for []interface {} of length 1"}},
+		}}},
+		{"/X[two]", &Report{Expr: "/X[two]", Steps: []Step{
+			{Segment: "/X[two]", In: 1, Reasons: []string{"key [two] is not a literal for string"}},
+		}}},
+		{`/X["two"]`, &Report{Expr: `/X["two"]`, Steps: []Step{
+			{Segment: `/X["two"]`, In: 1, Reasons: []string{`map[string]int has no entry for key ["two"]`}},
+		}}},
+		{"/A[*]/B", &Report{Expr: "/A[*]/B", Results: 1, Steps: []Step{
+			{Segment: "/A[*]", In: 1, Out: 2},
+			{Segment: "/B", In: 2, Out: 1, Reasons: []string{"nil interface {}"}},
+		}}},
+		{"/Child/S/.[0]", &Report{Expr: "/Child/S/.[0]", Results: 1, Steps: []Step{
+			{Segment: "/Child", In: 1, Out: 1},
+			{Segment: "/S", In: 1, Out: 1},
+			{Segment: "/.[0]", In: 1, Out: 1},
+		}}},
+		{"/Child/X", &Report{Expr: "/Child/X", Steps: []Step{
+			{Segment: "/Child", In: 1, Out: 1},
+			{Segment: "/X", In: 1, Out: 1},
+			{Segment: "(result)", In: 1, Reasons: []string{"nil interface {}"}},
+		}}},
+	}
+
+	for _, test := range tests {
+		verify.Values(t, test.expr, Explain(test.expr, root), test.want)
+	}
+}
+
+func TestExplainMalformed(t *testing.T) {
+	r := Explain("/A[0", Node{})
+	if r.Err == nil {
+		t.Fatal("no error for malformed expression")
+	}
+	if r.Results != 0 || len(r.Steps) != 0 {
+		t.Errorf("got %+v", r)
+	}
+}
+
+func TestExplainPolicy(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		expr   string
+		want   *Report
+	}{
+		{Policy{ExportedOnly: true}, "/.[0]/private", &Report{Expr: "/.[0]/private", Steps: []Step{
+			{Segment: "/.[0]", In: 1, Out: 1},
+			{Segment: "/private", In: 1, Reasons: []string{`type el.Cache has no field "private"`}},
+		}}},
+		{Policy{Tag: "json"}, "/.[1]/*", &Report{Expr: "/.[1]/*", Results: 1, Steps: []Step{
+			{Segment: "/.[1]", In: 1, Out: 1},
+			{Segment: "/*", In: 1, Out: 2},
+			{Segment: "(result)", In: 2, Out: 1, Reasons: []string{"nil map[string]string"}},
+		}}},
+		{Policy{Tag: "json"}, "/.[1]/@json:-", &Report{Expr: "/.[1]/@json:-", Steps: []Step{
+			{Segment: "/.[1]", In: 1, Out: 1},
+			{Segment: "/@json:-", In: 1, Reasons: []string{`type el.Cache has no field with json name "-"`}},
+		}}},
+	}
+	for _, test := range tests {
+		x, err := test.policy.Compile(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, test.expr, x.Explain(testCaches), test.want)
+	}
+
+	x, err := Limits{MaxResults: 1}.Compile("/.[*]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := x.Explain(testCaches); r.Err == nil {
+		t.Errorf("got %+v, want limits error", r)
+	}
+}
@@ -0,0 +1,192 @@
+package el
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Expr is a compiled expression. The package-level functions parse their
+// expression argument on each invocation, while Expr does so only once.
+// Struct field lookups and map key literals are cached per type on top of
+// that. Expressions are safe for concurrent use.
+type Expr struct {
+	src  string
+	path []segment
+	// x is the syntax tree for expressions which are not a path.
+	x operand
+	// limits bounds each evaluation, if any.
+	limits *Limits
+	// policy restricts each evaluation, if any.
+	policy *Policy
+	// noRead and noWrite are the denials from the path patterns of policy.
+	noRead, noWrite bool
+}
+
+// Compile parses expr for evaluation.
+func Compile(expr string) (*Expr, error) {
+	if !isPath(expr) {
+		x, err := parseOperation("expression", expr)
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{src: expr, x: x}, nil
+	}
+
+	path, err := parsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range path {
+		path[i].fields = new(sync.Map)
+		path[i].keys = new(sync.Map)
+	}
+	return &Expr{src: expr, path: path}, nil
+}
+
+// MustCompile is like Compile, but it panics on error.
+func MustCompile(expr string) *Expr {
+	x, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// IsPath returns whether the expression is a path, as opposed to a composition
+// with operators or functions. Only paths apply to modification.
+func (x *Expr) IsPath() bool {
+	return x.x == nil
+}
+
+// Segment is a path component in its normalized notation.
+type Segment struct {
+	// Selection is the field selection, like "Name", "*", "**",
+	// "@json:name" or "Format(\"2006\")", with "" for none.
+	Selection string
+	// Key is the key selection inbetween the square brackets, like "7",
+	// "*", `"x"`, "1:3" or "?Load > 80", with "" for none.
+	Key string
+	// Call marks a method invocation as the selection.
+	Call bool
+}
+
+// Segments returns the components of a path, with "." and ".." resolved. The
+// return is nil for expressions which are not a path.
+func (x *Expr) Segments() []Segment {
+	if x.x != nil {
+		return nil
+	}
+	a := make([]Segment, len(x.path))
+	for i := range x.path {
+		seg := &x.path[i]
+		a[i].Key = seg.key
+		a[i].Call = seg.call
+		if seg.call || seg.tag != "" || seg.selection != "" {
+			a[i].Selection = seg.selectionString()
+		}
+	}
+	return a
+}
+
+func (x *Expr) eval(root interface{}, ev *evaluation) []reflect.Value {
+	ev = x.constrain(ev)
+	if !x.permits(ev) {
+		return nil
+	}
+	var track []reflect.Value
+	if x.x != nil {
+		if ev.modifies() {
+			return nil // modification applies to paths only
+		}
+		track = x.x.eval(reflect.ValueOf(root), ev)
+	} else {
+		track = resolve(x.path, root, ev)
+	}
+	if ev.voided() {
+		return nil
+	}
+	if !ev.modifies() {
+		for i, v := range track {
+			track[i] = ev.conceal(v)
+		}
+	}
+	return track
+}
+
+// Assign is like the package-level function with the same name.
+func (x *Expr) Assign(root interface{}, want interface{}) (n int) {
+	ev := &evaluation{build: true}
+	return assign(x.eval(root, ev), ev, want)
+}
+
+// AssignText is like the package-level function with the same name.
+func (x *Expr) AssignText(root interface{}, text string) (n int) {
+	ev := &evaluation{build: true}
+	return assignText(x.eval(root, ev), ev, text)
+}
+
+// Bool is like the package-level function with the same name.
+func (x *Expr) Bool(root interface{}) (result bool, ok bool) {
+	return boolResult(x.eval(root, nil))
+}
+
+// Int is like the package-level function with the same name.
+func (x *Expr) Int(root interface{}) (result int64, ok bool) {
+	return intResult(x.eval(root, nil))
+}
+
+// Uint is like the package-level function with the same name.
+func (x *Expr) Uint(root interface{}) (result uint64, ok bool) {
+	return uintResult(x.eval(root, nil))
+}
+
+// Float is like the package-level function with the same name.
+func (x *Expr) Float(root interface{}) (result float64, ok bool) {
+	return floatResult(x.eval(root, nil))
+}
+
+// Complex is like the package-level function with the same name.
+func (x *Expr) Complex(root interface{}) (result complex128, ok bool) {
+	return complexResult(x.eval(root, nil))
+}
+
+// String is like the package-level function with the same name.
+func (x *Expr) String(root interface{}) (result string, ok bool) {
+	return stringResult(x.eval(root, nil))
+}
+
+// Any is like the package-level function with the same name.
+func (x *Expr) Any(root interface{}) []interface{} {
+	return anyResult(x.eval(root, nil))
+}
+
+// Bools is like the package-level function with the same name.
+func (x *Expr) Bools(root interface{}) []bool {
+	return boolsResult(x.eval(root, nil))
+}
+
+// Ints is like the package-level function with the same name.
+func (x *Expr) Ints(root interface{}) []int64 {
+	return intsResult(x.eval(root, nil))
+}
+
+// Uints is like the package-level function with the same name.
+func (x *Expr) Uints(root interface{}) []uint64 {
+	return uintsResult(x.eval(root, nil))
+}
+
+// Floats is like the package-level function with the same name.
+func (x *Expr) Floats(root interface{}) []float64 {
+	return floatsResult(x.eval(root, nil))
+}
+
+// Complexes is like the package-level function with the same name.
+func (x *Expr) Complexes(root interface{}) []complex128 {
+	return complexesResult(x.eval(root, nil))
+}
+
+// Strings is like the package-level function with the same name.
+func (x *Expr) Strings(root interface{}) []string {
+	return stringsResult(x.eval(root, nil))
+}
@@ -0,0 +1,182 @@
+package el
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestCompiledPaths(t *testing.T) {
+	for i, gold := range append(goldenPaths, goldenPathFails...) {
+		x, err := Compile(gold.expr)
+		if err != nil {
+			if gold.want != nil {
+				t.Errorf("%d: compile %q error: %s", i, gold.expr, err)
+			}
+			continue
+		}
+
+		// twice for cache hits
+		for j := 0; j < 2; j++ {
+			testGoldenCase(t, ignoreExpr(x.Bool), gold, i)
+			testGoldenCase(t, ignoreExpr(x.Int), gold, i)
+			testGoldenCase(t, ignoreExpr(x.Uint), gold, i)
+			testGoldenCase(t, ignoreExpr(x.Float), gold, i)
+			testGoldenCase(t, ignoreExpr(x.Complex), gold, i)
+			testGoldenCase(t, ignoreExpr(x.String), gold, i)
+		}
+	}
+}
+
+// ignoreExpr returns f with an additional (leading) string argument, such that
+// it matches the signature of the package-level equivalent.
+func ignoreExpr(f interface{}) reflect.Value {
+	v := reflect.ValueOf(f)
+	in := []reflect.Type{reflect.TypeOf("")}
+	for i := 0; i < v.Type().NumIn(); i++ {
+		in = append(in, v.Type().In(i))
+	}
+	var out []reflect.Type
+	for i := 0; i < v.Type().NumOut(); i++ {
+		out = append(out, v.Type().Out(i))
+	}
+
+	t := reflect.FuncOf(in, out, false)
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		return v.Call(args[1:])
+	})
+}
+
+func TestCompiledAssigns(t *testing.T) {
+	for _, gold := range append(newGoldenAssigns(), newGoldenAssignFails()...) {
+		x, err := Compile(gold.path)
+		if err != nil {
+			if gold.updates != 0 {
+				t.Errorf("compile %q error: %s", gold.path, err)
+			}
+			continue
+		}
+
+		n := x.Assign(gold.root, gold.value)
+		if n != gold.updates {
+			t.Errorf("Got n=%d, want %d for %s", n, gold.updates, gold.path)
+		}
+
+		got := x.Strings(gold.root)
+		verify.Values(t, gold.path, got, gold.result)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"Malformed",
+		"/[0]",
+		"/A[]",
+		"/A[0",
+		"/A[0]B",
+		"/A[0][1]",
+		`/A["]`,
+		`/A['\']`,
+	} {
+		if x, err := Compile(expr); err == nil {
+			t.Errorf("compile %q got %#v, want error", expr, x)
+		}
+	}
+}
+
+func TestCompileNormalization(t *testing.T) {
+	root := map[string]map[string]string{"a/b": {"c": "d"}}
+	for _, expr := range []string{
+		`/.["a/b"]/.["c"]`,
+		`/./.["a/b"]//.["c"]/`,
+		`/.["x"]/../.["a/b"]/.["c"]`,
+		`/../.["a/b"]/.["c"]`,
+	} {
+		got, ok := MustCompile(expr).String(root)
+		if !ok || got != "d" {
+			t.Errorf("%q: got %q, %t, want \"d\", true", expr, got, ok)
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	golden := []struct {
+		expr string
+		want []Segment
+	}{
+		{"/", []Segment{}},
+		{`/A/./B[7]/../.["a/b"]`, []Segment{{Selection: "A"}, {Key: `"a/b"`}}},
+		{"/**/@json:*/@yaml:\"a b\"[?X > 1]", []Segment{{Selection: "**"}, {Selection: "@json:*"}, {Selection: `@yaml:"a b"`, Key: "?X > 1"}}},
+		{`/T/Format("2006")[1:3]`, []Segment{{Selection: "T"}, {Selection: `Format("2006")`, Key: "1:3", Call: true}}},
+		{"len(/A) + 1", nil},
+	}
+	for _, gold := range golden {
+		verify.Values(t, gold.expr, MustCompile(gold.expr).Segments(), gold.want)
+	}
+}
+
+type Embedded struct {
+	E string
+}
+
+func TestNilEmbeddedPointer(t *testing.T) {
+	var x struct{ *Embedded }
+	if got, ok := String("/E", x); ok {
+		t.Errorf("got %q from nil embedding", got)
+	}
+
+	if n := Assign(&x, "/E", "set"); n != 1 {
+		t.Fatalf("got %d assigns, want 1", n)
+	}
+	if x.Embedded == nil || x.E != "set" {
+		t.Errorf("got %+v, want E \"set\"", x)
+	}
+}
+
+func TestCompiledConcurrency(t *testing.T) {
+	x := MustCompile("/X/A[1]")
+	roots := []interface{}{
+		Node{X: Node{A: [2]interface{}{nil, "a"}}},
+		Node{X: &Node{A: [2]interface{}{nil, "b"}}},
+		struct{ X struct{ A []string } }{X: struct{ A []string }{A: []string{"", "c"}}},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for k, root := range roots {
+					want := string([]byte{byte('a' + k)})
+					if got, ok := x.String(root); !ok || got != want {
+						t.Errorf("got %q, %t, want %q, true", got, ok, want)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkCompiledLookups(b *testing.B) {
+	exprs := make([]*Expr, len(goldenPaths))
+	for i, g := range goldenPaths {
+		exprs[i] = MustCompile(g.expr)
+	}
+	b.ResetTimer()
+
+	todo := b.N
+	for {
+		for i, g := range goldenPaths {
+			exprs[i].String(g.root)
+			todo--
+			if todo == 0 {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,554 @@
+package el
+
+import (
+	"fmt"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// operand is a node in a syntax tree.
+type operand interface {
+	// eval returns the values of the operand in the context of v. Paths
+	// resolve read-only, with the budget of ev, if any.
+	eval(v reflect.Value, ev *evaluation) []reflect.Value
+}
+
+// literal is a constant operand.
+type literal struct{ v reflect.Value }
+
+func (l literal) eval(reflect.Value, *evaluation) []reflect.Value {
+	return []reflect.Value{l.v}
+}
+
+// relPath is a path operand relative to the context.
+type relPath []segment
+
+func (p relPath) eval(v reflect.Value, ev *evaluation) []reflect.Value {
+	return resolveValue(p, v, ev.reads())
+}
+
+// logical is a boolean operator on two operands.
+type logical struct {
+	and  bool // or when false
+	x, y operand
+}
+
+func (l *logical) eval(v reflect.Value, ev *evaluation) []reflect.Value {
+	b := truth(l.x.eval(v, ev))
+	if b == l.and {
+		b = truth(l.y.eval(v, ev))
+	}
+	return []reflect.Value{reflect.ValueOf(b)}
+}
+
+// comparison is a relational operator on two operands. Operands with multiple
+// values match when any of the combinations does.
+type comparison struct {
+	op   string
+	x, y operand
+}
+
+func (c *comparison) eval(v reflect.Value, ev *evaluation) []reflect.Value {
+	xs, ys := c.x.eval(v, ev), c.y.eval(v, ev)
+	for _, x := range xs {
+		for _, y := range ys {
+			if compare(x, y, c.op) {
+				return []reflect.Value{reflect.ValueOf(true)}
+			}
+		}
+	}
+	return []reflect.Value{reflect.ValueOf(false)}
+}
+
+// truth returns whether any of the values is true. Non-boolean values count as
+// true when present.
+func truth(values []reflect.Value) bool {
+	for _, v := range values {
+		v = follow(v, false)
+		switch v.Kind() {
+		case reflect.Invalid:
+			continue
+		case reflect.Bool:
+			if v.Bool() {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// compare returns whether x op y holds.
+func compare(x, y reflect.Value, op string) bool {
+	x, y = follow(x, false), follow(y, false)
+
+	var c int // comparison result
+	switch xk, yk := kindClass(x.Kind()), kindClass(y.Kind()); {
+	case xk == reflect.Int && yk == reflect.Int:
+		c = compareInts(x.Int(), y.Int())
+	case xk == reflect.Uint && yk == reflect.Uint:
+		c = compareUints(x.Uint(), y.Uint())
+	case xk == reflect.Int && yk == reflect.Uint:
+		if x.Int() < 0 {
+			c = -1
+		} else {
+			c = compareUints(uint64(x.Int()), y.Uint())
+		}
+	case xk == reflect.Uint && yk == reflect.Int:
+		if y.Int() < 0 {
+			c = 1
+		} else {
+			c = compareUints(x.Uint(), uint64(y.Int()))
+		}
+	case isNumber(xk) && isNumber(yk):
+		a, b := asFloat(x), asFloat(y)
+		switch {
+		case a < b:
+			c = -1
+		case a > b:
+			c = 1
+		case a != b:
+			return false // NaN
+		}
+	case xk == reflect.String && yk == reflect.String:
+		c = strings.Compare(x.String(), y.String())
+	case xk == reflect.Bool && yk == reflect.Bool:
+		if x.Bool() != y.Bool() {
+			return op == "!="
+		}
+		return op == "=="
+	default:
+		return false
+	}
+
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// kindClass returns the representative kind for numbers and k otherwise.
+func kindClass(k reflect.Kind) reflect.Kind {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	}
+	return k
+}
+
+func isNumber(class reflect.Kind) bool {
+	return class == reflect.Int || class == reflect.Uint || class == reflect.Float64
+}
+
+func asFloat(v reflect.Value) float64 {
+	switch kindClass(v.Kind()) {
+	case reflect.Int:
+		return float64(v.Int())
+	case reflect.Uint:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareUints(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// parseFilter returns the predicate from a "?" key selection.
+func parseFilter(s string) (operand, error) {
+	return parseOperation("filter", s)
+}
+
+// parseOperation returns the syntax tree of s. Kind names s in errors.
+//
+//	expr       ::= or-expr | or-expr "?" expr ":" expr
+//	or-expr    ::= and-expr | or-expr "||" and-expr
+//	and-expr   ::= comparison | and-expr "&&" comparison
+//	comparison ::= sum | sum comparator sum
+//	comparator ::= "==" | "!=" | "<" | "<=" | ">" | ">="
+//	sum        ::= product | sum "+" product | sum "-" product
+//	product    ::= unary | product "*" unary | product "/" unary
+//	unary      ::= operand | "!" unary | "-" unary
+//	operand    ::= go-literal | path | call | "(" expr ")"
+//	call       ::= function-name "(" arguments ")"
+//	arguments  ::= expr | arguments "," expr
+func parseOperation(kind, s string) (operand, error) {
+	p := &parser{kind: kind, src: s}
+	x := p.parseExpr()
+	p.skipSpace()
+	if p.err == nil && p.i < len(p.src) {
+		p.fail("unexpected %q", p.src[p.i:])
+	}
+	return x, p.err
+}
+
+// parser is a recursive descent on src.
+type parser struct {
+	kind string // name for errors
+	src  string
+	i    int // read offset
+	err  error
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("goe el: %s %q offset %d: "+format, append([]interface{}{p.kind, p.src, p.i}, args...)...)
+	}
+}
+
+func (p *parser) skipSpace() {
+	for p.i < len(p.src) && (p.src[p.i] == ' ' || p.src[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// accept consumes token when next.
+func (p *parser) accept(token string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.i:], token) {
+		p.i += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseExpr() operand {
+	x := p.parseOr()
+	if p.err != nil || !p.accept("?") {
+		return x
+	}
+	c := &conditional{cond: x, x: p.parseExpr()}
+	if p.err == nil && !p.accept(":") {
+		p.fail("colon of conditional missing")
+	}
+	c.y = p.parseExpr()
+	return c
+}
+
+func (p *parser) parseOr() operand {
+	x := p.parseAnd()
+	for p.err == nil && p.accept("||") {
+		x = &logical{x: x, y: p.parseAnd()}
+	}
+	return x
+}
+
+func (p *parser) parseAnd() operand {
+	x := p.parseComparison()
+	for p.err == nil && p.accept("&&") {
+		x = &logical{and: true, x: x, y: p.parseComparison()}
+	}
+	return x
+}
+
+func (p *parser) parseComparison() operand {
+	x := p.parseSum()
+	// longest match first
+	for _, op := range [...]string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.err == nil && p.accept(op) {
+			return &comparison{op: op, x: x, y: p.parseSum()}
+		}
+	}
+	return x
+}
+
+func (p *parser) parseSum() operand {
+	x := p.parseProduct()
+	for p.err == nil {
+		switch {
+		case p.accept("+"):
+			x = &arithmetic{op: '+', x: x, y: p.parseProduct()}
+		case p.accept("-"):
+			x = &arithmetic{op: '-', x: x, y: p.parseProduct()}
+		default:
+			return x
+		}
+	}
+	return x
+}
+
+func (p *parser) parseProduct() operand {
+	x := p.parseUnary()
+	for p.err == nil {
+		switch {
+		case p.accept("*"):
+			x = &arithmetic{op: '*', x: x, y: p.parseUnary()}
+		case p.accept("/"):
+			x = &arithmetic{op: '/', x: x, y: p.parseUnary()}
+		default:
+			return x
+		}
+	}
+	return x
+}
+
+func (p *parser) parseUnary() operand {
+	p.skipSpace()
+	switch {
+	case strings.HasPrefix(p.src[p.i:], "!") && !strings.HasPrefix(p.src[p.i:], "!="):
+		p.i++
+		return &not{p.parseUnary()}
+	case strings.HasPrefix(p.src[p.i:], "-") && !p.numberNext(1):
+		p.i++
+		return &negation{p.parseUnary()}
+	}
+	return p.parseOperand()
+}
+
+// numberNext returns whether a digit or a decimal point is at offset n from
+// the read offset.
+func (p *parser) numberNext(n int) bool {
+	if p.i+n >= len(p.src) {
+		return false
+	}
+	c := p.src[p.i+n]
+	return c == '.' || c >= '0' && c <= '9'
+}
+
+func (p *parser) parseOperand() operand {
+	p.skipSpace()
+	if p.i >= len(p.src) {
+		p.fail("operand missing")
+		return nil
+	}
+
+	switch c := p.src[p.i]; {
+	case c == '(':
+		p.i++
+		x := p.parseExpr()
+		if p.err == nil && !p.accept(")") {
+			p.fail("closing parenthesis missing")
+		}
+		return x
+
+	case c == '"' || c == '`':
+		end := p.literalEnd(c)
+		s, err := strconv.Unquote(p.src[p.i:end])
+		if err != nil {
+			p.fail("%s", err)
+		}
+		p.i = end
+		return literal{reflect.ValueOf(s)}
+
+	case c == '\'':
+		end := p.literalEnd(c)
+		s, err := strconv.Unquote(p.src[p.i:end])
+		if err != nil || len([]rune(s)) != 1 {
+			p.fail("malformed character literal")
+			return nil
+		}
+		p.i = end
+		return literal{reflect.ValueOf(int64([]rune(s)[0]))}
+
+	case (c == '-' || c == '+') && p.numberNext(1), c >= '0' && c <= '9':
+		return p.parseNumber()
+
+	default:
+		// methods are exported, and functions are not
+		if name, ok := p.functionNext(); ok && !token.IsExported(name) {
+			return p.parseCall(name)
+		}
+		return p.parsePath()
+	}
+}
+
+// functionNext returns the name of the function invoked at the read offset,
+// if any.
+func (p *parser) functionNext() (name string, ok bool) {
+	end := p.i
+	for end < len(p.src) && (p.src[end] == '_' || unicode.IsLetter(rune(p.src[end])) || unicode.IsDigit(rune(p.src[end]))) {
+		end++
+	}
+	if end == p.i || end >= len(p.src) || p.src[end] != '(' {
+		return "", false
+	}
+	return p.src[p.i:end], true
+}
+
+func (p *parser) parseCall(name string) operand {
+	f, ok := functions[name]
+	if !ok {
+		p.fail("unknown function %s", name)
+		return nil
+	}
+	p.i += len(name) + 1 // opening parenthesis
+
+	c := &call{f: f}
+	if !p.accept(")") {
+		for p.err == nil {
+			c.args = append(c.args, p.parseExpr())
+			if p.err != nil || p.accept(")") {
+				break
+			}
+			if !p.accept(",") {
+				p.fail("closing parenthesis of %s missing", name)
+			}
+		}
+	}
+	if p.err == nil && (len(c.args) < f.minArgs || f.maxArgs >= 0 && len(c.args) > f.maxArgs) {
+		p.fail("function %s does not take %d arguments", name, len(c.args))
+	}
+	if p.err == nil && name == "len" && !singular(c.args[0]) {
+		c.f = functions["count"]
+	}
+	if p.err == nil && name == "matches" {
+		if l, ok := c.args[1].(literal); ok && l.v.Kind() == reflect.String {
+			if _, err := compilePattern(l.v.String()); err != nil {
+				p.fail("%s", err)
+			}
+		}
+	}
+	return c
+}
+
+// singular returns whether x has one value at most by its notation. Paths with
+// a wildcard, a recursive descent, a filter or a range may have more.
+func singular(x operand) bool {
+	switch x := x.(type) {
+	case relPath:
+		for i := range x {
+			seg := &x[i]
+			if seg.descent || seg.tagAny || seg.tag == "" && seg.selection == "*" || seg.key == "*" || seg.filter != nil || seg.elements != nil {
+				return false
+			}
+		}
+	case *conditional:
+		return singular(x.x) && singular(x.y)
+	}
+	return true
+}
+
+// literalEnd returns the offset after the quoted literal at the read offset.
+func (p *parser) literalEnd(quote byte) int {
+	for i := p.i + 1; i < len(p.src); i++ {
+		switch p.src[i] {
+		case quote:
+			return i + 1
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		}
+	}
+	p.fail("unterminated literal")
+	return len(p.src)
+}
+
+func (p *parser) parseNumber() operand {
+	end := p.i + 1
+	for end < len(p.src) && strings.IndexByte("0123456789abcdefABCDEFoOxX._+-", p.src[end]) >= 0 {
+		// sign only after exponent
+		if c := p.src[end]; (c == '+' || c == '-') && !strings.ContainsAny(p.src[end-1:end], "eEpP") {
+			break
+		}
+		end++
+	}
+	s := p.src[p.i:end]
+	p.i = end
+
+	if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return literal{reflect.ValueOf(i)}
+	}
+	if u, err := strconv.ParseUint(s, 0, 64); err == nil {
+		return literal{reflect.ValueOf(u)}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		p.fail("malformed number %q", s)
+	}
+	return literal{reflect.ValueOf(f)}
+}
+
+// parsePath reads a path relative to the context, or a boolean literal.
+// Operators other than comparisons and logical ones need white space after a
+// path, as their characters are valid in path components.
+func (p *parser) parsePath() operand {
+	offset := p.i
+	for p.i < len(p.src) {
+		c := p.src[p.i]
+		switch c {
+		case '[':
+			end, err := bracketEnd(p.src, p.i)
+			if err != nil {
+				p.fail("%s", err)
+				return nil
+			}
+			p.i = end
+			continue
+		case '"', '`':
+			p.i = p.literalEnd(c)
+			continue
+		case '(':
+			if p.i == offset || !isNameChar(p.src[p.i-1]) {
+				break
+			}
+			end, err := parenEnd(p.src, p.i)
+			if err != nil {
+				p.fail("%s", err)
+				return nil
+			}
+			p.i = end
+			continue
+		}
+		if strings.IndexByte(" \t()=!<>&|?,", c) >= 0 {
+			break
+		}
+		p.i++
+	}
+
+	switch s := p.src[offset:p.i]; s {
+	case "":
+		p.fail("operand missing")
+		return nil
+	case "true":
+		return literal{reflect.ValueOf(true)}
+	case "false":
+		return literal{reflect.ValueOf(false)}
+	default:
+		if p.kind == "expression" && s[0] != '/' {
+			p.fail("path %q is not absolute", s)
+			return nil
+		}
+		path, err := parsePath("/" + s)
+		if err != nil {
+			p.fail("%s", err)
+		}
+		return relPath(path)
+	}
+}
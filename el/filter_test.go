@@ -0,0 +1,111 @@
+package el
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+type Order struct {
+	ID    string
+	Total float64
+	Items []string
+	Rush  bool
+	Ship  *struct{ Zone uint8 }
+}
+
+var testOrders = map[string][]*Order{
+	"open": {
+		{ID: "a", Total: 99.5, Items: []string{"pen"}},
+		{ID: "b", Total: 100, Items: []string{"ink", "pen"}, Rush: true},
+		{ID: "c", Total: 250, Ship: &struct{ Zone uint8 }{Zone: 2}},
+	},
+	"done": {
+		{ID: "d", Total: 1e3, Rush: true, Ship: &struct{ Zone uint8 }{Zone: 7}},
+	},
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{`/.["open"]/.[?ID=="b"]/ID`, []string{"b"}},
+		{`/.["open"]/.[?ID == 'b']/ID`, nil},
+		{`/.["open"]/.[?Total > 100]/ID`, []string{"c"}},
+		{`/.["open"]/.[?Total >= 100]/ID`, []string{"b", "c"}},
+		{`/.["open"]/.[?Total < -1]/ID`, nil},
+		{`/.["open"]/.[?Total != 100 && Total <= 250]/ID`, []string{"a", "c"}},
+		{`/.["open"]/.[?ID == "a" || Rush]/ID`, []string{"a", "b"}},
+		{`/.["open"]/.[?Rush == false]/ID`, []string{"a", "c"}},
+		{`/.["open"]/.[?Ship]/ID`, []string{"c"}},
+		{`/.["open"]/.[?Ship/Zone == 2]/ID`, []string{"c"}},
+		{`/.["open"]/.[?Items[*] == "pen"]/ID`, []string{"a", "b"}},
+		{`/.["open"]/.[?Items[1]]/ID`, []string{"b"}},
+		{`/.["open"]/.[?(ID == "a" || ID == "c") && Total > 200]/ID`, []string{"c"}},
+		{`/.[*]/.[?Ship/Zone > 1]/ID`, []string{"c", "d"}},
+		{`/.["open"]/.[?ID == "c"]/Items[?. == "pen"]`, nil},
+		{`/.["open"]/.[?ID == "b"]/Items[?. != "pen"]`, []string{"ink"}},
+	}
+
+	for _, test := range tests {
+		got := Strings(test.expr, testOrders)
+		if len(got) == 0 {
+			got = nil
+		}
+		verify.Values(t, test.expr, sorted(got), test.want)
+	}
+}
+
+func TestFilterMapValues(t *testing.T) {
+	got := Ints(`/.[?. > 1]`, map[string]int{"one": 1, "two": 2, "three": 3})
+	if len(got) != 2 || got[0]+got[1] != 5 {
+		t.Errorf("got %d, want 2 and 3", got)
+	}
+}
+
+func TestFilterAssign(t *testing.T) {
+	x := &struct {
+		Orders []Order
+		Index  map[string]Order
+	}{
+		Orders: []Order{{ID: "a", Total: 1}, {ID: "b", Total: 2}, {ID: "c", Total: 3}},
+		Index:  map[string]Order{"x": {Total: 1}, "y": {Total: 2}},
+	}
+
+	if n := Assign(x, "/Orders[?Total >= 2]/Rush", true); n != 2 {
+		t.Errorf("got %d slice updates, want 2", n)
+	}
+	verify.Values(t, "rush orders", Strings("/Orders[?Rush]/ID", x), []string{"b", "c"})
+
+	if n := Assign(x, "/Index[?Total == 2]/ID", "y"); n != 1 {
+		t.Errorf("got %d map updates, want 1", n)
+	}
+	verify.Values(t, "map update", x.Index["y"].ID, "y")
+}
+
+func TestFilterCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		"/A[?]",
+		"/A[?B ==]",
+		"/A[?(B == 1]",
+		`/A[?B == "x]`,
+		"/A[?B == 1 C]",
+		"/A[?B == 1x]",
+		"/A[?B == '']",
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("compile %q: no error", expr)
+		}
+	}
+}
+
+// sorted returns a in order (with insertion sort).
+func sorted(a []string) []string {
+	for i := 1; i < len(a); i++ {
+		for j := i; j > 0 && a[j] < a[j-1]; j-- {
+			a[j], a[j-1] = a[j-1], a[j]
+		}
+	}
+	return a
+}
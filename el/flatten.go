@@ -0,0 +1,124 @@
+package el
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Flatten returns each leaf of root by its canonical path, conform Locate.
+// Leaves are all values other than structs, arrays, slices and maps, with the
+// exception of byte slices and structs without exported fields, like time.Time,
+// which are leaves too. Non-exported fields are omitted, and so are nil pointers
+// and nil interfaces. Pointers on the way to a value are not followed again,
+// such that reference cycles terminate. Shared content appears on each path.
+//
+// The result is deterministic, i.e., the same content gives the same paths.
+func Flatten(root interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	flatten(m, located{v: reflect.ValueOf(root)}, make(map[visit]struct{}))
+	return m
+}
+
+// flatten puts the leaves of l in dst. The seen set holds the references on
+// the way to l only.
+func flatten(dst map[string]interface{}, l located, seen map[visit]struct{}) {
+	var stack []visit
+	defer func() {
+		for _, key := range stack {
+			delete(seen, key)
+		}
+	}()
+
+	v := l.v
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Ptr {
+			key := visit{p: v.Pointer(), typ: v.Type()}
+			if _, ok := seen[key]; ok {
+				return
+			}
+			seen[key] = struct{}{}
+			stack = append(stack, key)
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		var exported bool
+		for i, n := 0, t.NumField(); i < n; i++ {
+			if f := t.Field(i); f.PkgPath == "" {
+				exported = true
+				flatten(dst, l.field(f.Name, v.Field(i)), seen)
+			}
+		}
+		if exported {
+			return
+		}
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break // leaf
+		}
+		if v.IsNil() {
+			return
+		}
+		key := visit{p: v.Pointer(), n: v.Len(), typ: v.Type()}
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		stack = append(stack, key)
+		fallthrough
+	case reflect.Array:
+		for i, n := 0, v.Len(); i < n; i++ {
+			flatten(dst, l.element(i, v.Index(i)), seen)
+		}
+		return
+
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		key := visit{p: v.Pointer(), typ: v.Type()}
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		stack = append(stack, key)
+
+		iter := v.MapRange()
+		for iter.Next() {
+			flatten(dst, l.key(keyLiteral(iter.Key()), iter.Value()), seen)
+		}
+		return
+
+	}
+
+	if x := asInterface(v); x != nil {
+		path := l.path
+		if path == "" {
+			path = "/"
+		}
+		dst[path] = x
+	}
+}
+
+// Unflatten applies each value in m to its path on dst with Assign, in order
+// of the paths, and it returns the number of successes. Paths from Flatten
+// restore the leaves, including any content in between.
+func Unflatten(dst interface{}, m map[string]interface{}) (n int) {
+	paths := make([]string, 0, len(m))
+	for path := range m {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		n += Assign(dst, path, m[path])
+	}
+	return n
+}
@@ -0,0 +1,118 @@
+package el
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+type config struct {
+	Name     string
+	Port     uint16
+	Ratio    float32
+	Enabled  bool
+	Key      []byte
+	Started  time.Time
+	Hosts    []string
+	Limits   map[string]int
+	Backends []*Service
+	Grid     [2][]int
+	secret   string
+	Nil      *config
+}
+
+func TestFlatten(t *testing.T) {
+	started := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := &config{
+		Name:     "api",
+		Port:     8080,
+		Ratio:    0.5,
+		Enabled:  true,
+		Key:      []byte{1, 2},
+		Started:  started,
+		Hosts:    []string{"a", "b"},
+		Limits:   map[string]int{"I/O": 3},
+		Backends: []*Service{{Name: "db", Timeout: 2}},
+		Grid:     [2][]int{{7}},
+		secret:   "x",
+	}
+	c.Backends[0].Parent = c.Backends[0] // cycle
+
+	want := map[string]interface{}{
+		"/Name":                "api",
+		"/Port":                uint64(8080),
+		"/Ratio":               0.5,
+		"/Enabled":             true,
+		"/Key":                 []byte{1, 2},
+		"/Started":             started,
+		"/Hosts[0]":            "a",
+		"/Hosts[1]":            "b",
+		`/Limits["I\x2fO"]`:    int64(3),
+		"/Backends[0]/Name":    "db",
+		"/Backends[0]/Timeout": int64(2),
+		"/Grid[0]/.[0]":        int64(7),
+	}
+	verify.Values(t, "flat", Flatten(c), want)
+
+	got := new(config)
+	if n := Unflatten(got, want); n != len(want) {
+		t.Errorf("got %d assigns, want %d", n, len(want))
+	}
+	c.secret = ""
+	c.Backends[0].Parent = nil
+	verify.Values(t, "round trip", got, c)
+
+	verify.Values(t, "root leaf", Flatten(42), map[string]interface{}{"/": int64(42)})
+}
+
+func TestFlattenShared(t *testing.T) {
+	s := &Service{Name: "db", Timeout: 2, Options: map[string]interface{}{"tls": true}}
+	s.Backends = []*Service{s} // cycle
+	hosts := []string{"a"}
+	limits := map[string]int{"n": 1}
+	c := &config{
+		Hosts:    hosts,
+		Limits:   limits,
+		Backends: []*Service{s, {Name: "cache", Options: s.Options}, s},
+		Grid:     [2][]int{{7}, nil},
+	}
+	c.Grid[1] = c.Grid[0]
+	c.Nil = &config{Hosts: hosts, Limits: limits}
+
+	want := map[string]interface{}{
+		"/Hosts[0]":                   "a",
+		`/Limits["n"]`:                int64(1),
+		"/Backends[0]/Name":           "db",
+		"/Backends[0]/Timeout":        int64(2),
+		`/Backends[0]/Options["tls"]`: true,
+		"/Backends[1]/Name":           "cache",
+		"/Backends[1]/Timeout":        int64(0),
+		`/Backends[1]/Options["tls"]`: true,
+		"/Backends[2]/Name":           "db",
+		"/Backends[2]/Timeout":        int64(2),
+		`/Backends[2]/Options["tls"]`: true,
+		"/Grid[0]/.[0]":               int64(7),
+		"/Grid[1]/.[0]":               int64(7),
+		"/Nil/Hosts[0]":               "a",
+		`/Nil/Limits["n"]`:            int64(1),
+		"/Port":                       uint64(0),
+		"/Name":                       "",
+		"/Ratio":                      0.0,
+		"/Enabled":                    false,
+		"/Key":                        []byte(nil),
+		"/Started":                    time.Time{},
+		"/Nil/Port":                   uint64(0),
+		"/Nil/Name":                   "",
+		"/Nil/Ratio":                  0.0,
+		"/Nil/Enabled":                false,
+		"/Nil/Key":                    []byte(nil),
+		"/Nil/Started":                time.Time{},
+	}
+	verify.Values(t, "flat", Flatten(c), want)
+
+	got := new(config)
+	Unflatten(got, Flatten(c))
+	s.Backends = nil // cycles do not restore
+	verify.Values(t, "round trip", got, c)
+}
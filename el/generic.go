@@ -0,0 +1,68 @@
+package el
+
+import "reflect"
+
+// Get returns the evaluation result if, and only if, the result has one value
+// and the value fits T. Values fit when they are assignable or convertible to
+// T, conform Assign. Addressable values also fit when their pointer does, such
+// that pointer receivers count for interfaces. Unlike Any and its kind-specific
+// siblings, the result retains named types, as in time.Duration.
+func Get[T any](expr string, root interface{}) (result T, ok bool) {
+	return getResult[T](eval(expr, root, nil))
+}
+
+// GetCompiled is like Get with a compiled expression. Go has no type parameters
+// on methods.
+func GetCompiled[T any](x *Expr, root interface{}) (result T, ok bool) {
+	return getResult[T](x.eval(root, nil))
+}
+
+func getResult[T any](a []reflect.Value) (result T, ok bool) {
+	if len(a) == 1 {
+		return typed[T](a[0])
+	}
+	return
+}
+
+// GetAll returns the evaluation result values which fit T, conform Get.
+func GetAll[T any](expr string, root interface{}) []T {
+	return getAllResult[T](eval(expr, root, nil))
+}
+
+// GetAllCompiled is like GetAll with a compiled expression. Go has no type
+// parameters on methods.
+func GetAllCompiled[T any](x *Expr, root interface{}) []T {
+	return getAllResult[T](x.eval(root, nil))
+}
+
+func getAllResult[T any](a []reflect.Value) []T {
+	var b []T
+	for _, v := range a {
+		if x, ok := typed[T](v); ok {
+			b = append(b, x)
+		}
+	}
+	return b
+}
+
+// typed returns v as a T, if it fits.
+func typed[T any](v reflect.Value) (result T, ok bool) {
+	if !v.IsValid() || !v.CanInterface() {
+		return
+	}
+
+	dst := reflect.ValueOf(&result).Elem()
+	t := dst.Type()
+	switch vt := v.Type(); {
+	case vt.AssignableTo(t):
+		break
+	case v.CanAddr() && reflect.PointerTo(vt).AssignableTo(t):
+		v = v.Addr()
+	case v.CanConvert(t):
+		v = v.Convert(t)
+	default:
+		return
+	}
+	dst.Set(v)
+	return result, true
+}
@@ -0,0 +1,82 @@
+package el
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestGet(t *testing.T) {
+	e := testEndpoint
+
+	if got, ok := Get[time.Duration]("/Timeout", e); !ok || got != e.Timeout {
+		t.Errorf("Get[time.Duration]: got %v, %t", got, ok)
+	}
+	if got, ok := Get[time.Time]("/Started", e); !ok || !got.Equal(e.Started) {
+		t.Errorf("Get[time.Time]: got %v, %t", got, ok)
+	}
+	if got, ok := Get[[]byte]("/X", Node{X: []byte{1, 2}}); !ok || string(got) != "\x01\x02" {
+		t.Errorf("Get[[]byte]: got %v, %t", got, ok)
+	}
+	if got, ok := Get[tagList]("/Tags", e); !ok || len(got) != 3 {
+		t.Errorf("Get[tagList]: got %v, %t", got, ok)
+	}
+	if got, ok := Get[url.URL]("/URL", e); !ok || got != *e.URL {
+		t.Errorf("Get[url.URL]: got %v, %t", got, ok)
+	}
+	if got, ok := Get[*url.URL]("/URL", e); !ok || got != e.URL {
+		t.Errorf("Get[*url.URL]: got %v, %t", got, ok)
+	}
+
+	// interfaces retain the dynamic type
+	if got, ok := Get[fmt.Stringer]("/Timeout", e); !ok || got.String() != "1m30s" {
+		t.Errorf("Get[fmt.Stringer] on value receiver: got %v, %t", got, ok)
+	}
+	if got, ok := Get[fmt.Stringer]("/URL", e); !ok || got.String() != "https://example.com:8443/api?q=1" {
+		t.Errorf("Get[fmt.Stringer] on pointer receiver: got %v, %t", got, ok)
+	}
+	if got, ok := Get[interface{}]("/X", Node{X: testV}); !ok || got != testV {
+		t.Errorf("Get[interface{}]: got %#v, %t", got, ok)
+	}
+
+	// conversions
+	if got, ok := Get[float64]("/I", testV); !ok || got != -2 {
+		t.Errorf("Get[float64]: got %v, %t", got, ok)
+	}
+	if got, ok := Get[int64]("/Timeout", e); !ok || got != int64(e.Timeout) {
+		t.Errorf("Get[int64]: got %v, %t", got, ok)
+	}
+
+	// no result
+	if got, ok := Get[bool]("/S", testV); ok {
+		t.Errorf("Get[bool] on string: got %v", got)
+	}
+	if got, ok := Get[string]("/Tags[*]", e); ok {
+		t.Errorf("Get[string] on multiple values: got %v", got)
+	}
+	if got, ok := Get[*Node]("/child", Node{child: &Node{}}); ok {
+		t.Errorf("Get[*Node] on non-exported field: got %v", got)
+	}
+	if got, ok := Get[string]("/Absent", e); ok {
+		t.Errorf("Get[string] on absent field: got %q", got)
+	}
+	if got, ok := Get[string]("/Name", nil); ok {
+		t.Errorf("Get[string] on nil: got %q", got)
+	}
+}
+
+func TestGetAll(t *testing.T) {
+	e := testEndpoint
+	verify.Values(t, "tags", GetAll[string]("/Tags[*]", e), []string{"a", "b", "c"})
+	verify.Values(t, "strings", GetAll[fmt.Stringer]("/*", e), []fmt.Stringer{e.URL, e.Timeout, e.Started})
+	verify.Values(t, "bools", GetAll[bool]("/Tags[*]", e), []bool(nil))
+
+	x := MustCompile("/Nodes[1:]/Load")
+	verify.Values(t, "compiled", GetAllCompiled[float64](x, testCluster), []float64{80, 30, 50})
+	if got, ok := GetCompiled[uint8](MustCompile("/Nodes[0]/Load"), testCluster); !ok || got != 10 {
+		t.Errorf("GetCompiled: got %v, %t", got, ok)
+	}
+}
@@ -0,0 +1,451 @@
+package el
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CompileJSONPointer parses an RFC 6901 JSON Pointer for evaluation.
+// See FromJSONPointer for the details.
+func CompileJSONPointer(ptr string) (*Expr, error) {
+	expr, err := FromJSONPointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(expr)
+}
+
+// CompileJSONPath parses a JSONPath for evaluation.
+// See FromJSONPath for the details.
+func CompileJSONPath(path string) (*Expr, error) {
+	expr, err := FromJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(expr)
+}
+
+// FromJSONPointer returns the GoEL equivalent of an RFC 6901 JSON Pointer.
+// Each reference token maps to a "@json:" selection, which addresses struct
+// fields by their JSON name, map entries by their key and array elements by
+// their index. The URI fragment representation, with a leading "#", is also
+// accepted.
+func FromJSONPointer(ptr string) (string, error) {
+	expr, err := fromJSONPointer(ptr)
+	if err != nil {
+		return "", fmt.Errorf("goe el: %w", err)
+	}
+	return expr, nil
+}
+
+// fromJSONPointer is FromJSONPointer without the package prefix on errors.
+func fromJSONPointer(ptr string) (string, error) {
+	if strings.HasPrefix(ptr, "#") {
+		s, err := url.PathUnescape(ptr[1:])
+		if err != nil {
+			return "", fmt.Errorf("JSON Pointer %q fragment: %w", ptr, err)
+		}
+		ptr = s
+	}
+	if ptr == "" {
+		return "/", nil
+	}
+	if ptr[0] != '/' {
+		return "", fmt.Errorf("JSON Pointer %q does not start with a slash", ptr)
+	}
+
+	var buf strings.Builder
+	for _, token := range strings.Split(ptr[1:], "/") {
+		for i := 0; i < len(token); i++ {
+			if token[i] == '~' && (i+1 >= len(token) || token[i+1] != '0' && token[i+1] != '1') {
+				return "", fmt.Errorf("JSON Pointer %q has an illegal escape sequence", ptr)
+			}
+		}
+		token = strings.ReplaceAll(token, "~1", "/")
+		token = strings.ReplaceAll(token, "~0", "~")
+
+		buf.WriteString("/@json:")
+		buf.WriteString(quoteTagName(token))
+	}
+	return buf.String(), nil
+}
+
+// ToJSONPointer returns the RFC 6901 JSON Pointer equivalent of a GoEL path.
+// Field selections by Go name pass as is, since the type is not known.
+// Wildcards, recursive descents, filters and element ranges have no such
+// equivalent.
+func ToJSONPointer(expr string) (string, error) {
+	path, err := parsePath(expr)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	for i := range path {
+		tokens, err := path[i].wireNames()
+		if err != nil {
+			return "", fmt.Errorf("goe el: expression %q has no JSON Pointer equivalent: %w", expr, err)
+		}
+		for _, token := range tokens {
+			buf.WriteByte('/')
+			buf.WriteString(escapeJSONPointer(token))
+		}
+	}
+	return buf.String(), nil
+}
+
+// errNoWireName signals a selection on multiple names.
+var errNoWireName = errors.New("selection is not a single name")
+
+// wireNames returns the JSON names for the selection and the key, if any.
+func (seg *segment) wireNames() ([]string, error) {
+	var names []string
+	switch {
+	case seg.call:
+		return nil, fmt.Errorf("%s: method invocation", seg)
+	case seg.descent, seg.tagAny, seg.selection == "*" && seg.tag == "":
+		return nil, fmt.Errorf("%s: %w", seg, errNoWireName)
+	case seg.tag != "" && seg.tag != "json":
+		return nil, fmt.Errorf("%s: not a JSON tag", seg)
+	case seg.tag != "" || seg.selection != "":
+		names = append(names, seg.selection)
+	}
+
+	if seg.key != "" {
+		name, err := seg.keyName()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// keyName returns the key selection as a JSON name.
+func (seg *segment) keyName() (string, error) {
+	switch {
+	case seg.filter != nil, seg.elements != nil, seg.key == "*":
+		return "", fmt.Errorf("%s: %w", seg, errNoWireName)
+	case seg.index >= 0:
+		return strconv.Itoa(seg.index), nil
+	}
+
+	switch seg.key[0] {
+	case '"', '`':
+		s, err := strconv.Unquote(seg.key)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", seg, err)
+		}
+		return s, nil
+	case '\'':
+		s, err := strconv.Unquote(seg.key)
+		if err != nil || len([]rune(s)) != 1 {
+			return "", fmt.Errorf("%s: malformed character literal", seg)
+		}
+		return strconv.Itoa(int([]rune(s)[0])), nil
+	}
+	if i, err := strconv.ParseInt(seg.key, 0, 64); err == nil {
+		return strconv.FormatInt(i, 10), nil
+	}
+	return seg.key, nil
+}
+
+// FromJSONPath returns the GoEL equivalent of a JSONPath. The supported subset
+// consists of the root "$", child selection with a dot or with brackets, the
+// wildcard, indices, unions-free slices, recursive descent with ".." and
+// filters "?()" with comparisons on "@" relative paths. Names map to "@json:"
+// selections.
+func FromJSONPath(path string) (string, error) {
+	if path == "" || path[0] != '$' {
+		return "", fmt.Errorf("goe el: JSONPath %q does not start with a dollar sign", path)
+	}
+
+	var buf strings.Builder
+	for i := 1; i < len(path); {
+		switch {
+		case strings.HasPrefix(path[i:], ".."):
+			buf.WriteString("/**")
+			i += 2
+			if i < len(path) && path[i] == '[' {
+				continue
+			}
+		case path[i] == '.':
+			i++
+		case path[i] == '[':
+			end, err := bracketEnd(path, i)
+			if err != nil {
+				return "", fmt.Errorf("goe el: JSONPath %q: %w", path, err)
+			}
+			s, err := fromJSONPathBracket(path[i+1 : end-1])
+			if err != nil {
+				return "", fmt.Errorf("goe el: JSONPath %q: %w", path, err)
+			}
+			buf.WriteString(s)
+			i = end
+			continue
+		default:
+			return "", fmt.Errorf("goe el: JSONPath %q has unexpected %q at offset %d", path, path[i], i)
+		}
+
+		// dot notation
+		end := i
+		for end < len(path) && path[end] != '.' && path[end] != '[' {
+			end++
+		}
+		name := path[i:end]
+		switch name {
+		case "":
+			return "", fmt.Errorf("goe el: JSONPath %q has an empty name at offset %d", path, i)
+		case "*":
+			buf.WriteString("/@json:*")
+		default:
+			buf.WriteString("/@json:")
+			buf.WriteString(quoteTagName(name))
+		}
+		i = end
+	}
+
+	expr := buf.String()
+	if expr == "" {
+		expr = "/"
+	}
+	if _, err := parsePath(expr); err != nil {
+		return "", fmt.Errorf("goe el: JSONPath %q: %w", path, err)
+	}
+	return expr, nil
+}
+
+// fromJSONPathBracket returns the GoEL equivalent of a JSONPath selection
+// inbetween square brackets.
+func fromJSONPathBracket(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return "", errors.New("empty brackets")
+	case s == "*":
+		return "/@json:*", nil
+	case s[0] == '?':
+		f, err := fromJSONPathFilter(s[1:])
+		if err != nil {
+			return "", err
+		}
+		return "/.[?" + f + "]", nil
+	case s[0] == '\'' || s[0] == '"':
+		name, err := unquoteJSONPath(s)
+		if err != nil {
+			return "", err
+		}
+		return "/@json:" + quoteTagName(name), nil
+	case strings.ContainsRune(s, ','):
+		return "", errors.New("unions not supported")
+	}
+
+	if _, ok := wireIndex(s); ok {
+		return "/@json:" + s, nil
+	}
+	if _, ok, err := parseRange(s); err != nil || !ok {
+		return "", fmt.Errorf("malformed selection [%s]", s)
+	}
+	return "/.[" + s + "]", nil
+}
+
+// fromJSONPathFilter returns the GoEL equivalent of a JSONPath filter.
+func fromJSONPathFilter(s string) (string, error) {
+	var buf strings.Builder
+	for i := 0; i < len(s); {
+		switch c := s[i]; c {
+		case '\'', '"':
+			end, err := literalEnd(s, i)
+			if err != nil {
+				return "", err
+			}
+			lit, err := unquoteJSONPath(s[i:end])
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(strconv.Quote(lit))
+			i = end
+
+		case '$':
+			return "", errors.New("filter with root reference not supported")
+
+		case '@':
+			i++
+			var segs []string
+			for i < len(s) {
+				if s[i] == '.' {
+					end := i + 1
+					for end < len(s) && (isNameByte(s[end]) || s[end] == '*') {
+						end++
+					}
+					name := s[i+1 : end]
+					if name == "*" {
+						segs = append(segs, "@json:*")
+					} else {
+						segs = append(segs, "@json:"+quoteTagName(name))
+					}
+					i = end
+					continue
+				}
+				if s[i] == '[' {
+					end, err := bracketEnd(s, i)
+					if err != nil {
+						return "", err
+					}
+					sel, err := fromJSONPathBracket(s[i+1 : end-1])
+					if err != nil {
+						return "", err
+					}
+					segs = append(segs, sel[1:])
+					i = end
+					continue
+				}
+				break
+			}
+			if len(segs) == 0 {
+				buf.WriteByte('.')
+			} else {
+				buf.WriteString(strings.Join(segs, "/"))
+			}
+
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+	return buf.String(), nil
+}
+
+// unquoteJSONPath returns the content of a single- or double-quoted literal.
+func unquoteJSONPath(s string) (string, error) {
+	if len(s) < 2 || s[0] != s[len(s)-1] || (s[0] != '\'' && s[0] != '"') {
+		return "", fmt.Errorf("malformed literal %s", s)
+	}
+	if s[0] == '\'' {
+		// swap quotes for strconv
+		s = `"` + strings.ReplaceAll(strings.ReplaceAll(s[1:len(s)-1], `\'`, `'`), `"`, `\"`) + `"`
+	}
+	name, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("malformed literal %s: %w", s, err)
+	}
+	return name, nil
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// ToJSONPath returns the JSONPath equivalent of a GoEL path. Field selections
+// by Go name pass as is, since the type is not known. Filters have no such
+// equivalent.
+func ToJSONPath(expr string) (string, error) {
+	path, err := parsePath(expr)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	buf.WriteByte('$')
+	descent := false
+	for i := range path {
+		seg := &path[i]
+		if seg.descent {
+			descent = true
+			continue
+		}
+		if seg.tag != "" && seg.tag != "json" {
+			return "", fmt.Errorf("goe el: expression %q has no JSONPath equivalent: %s: not a JSON tag", expr, seg)
+		}
+		if seg.call {
+			return "", fmt.Errorf("goe el: expression %q has no JSONPath equivalent: %s: method invocation", expr, seg)
+		}
+
+		switch {
+		case seg.tagAny, seg.tag == "" && seg.selection == "*":
+			if descent {
+				buf.WriteString("..*")
+			} else {
+				buf.WriteString(".*")
+			}
+		case seg.tag != "" || seg.selection != "":
+			if descent {
+				buf.WriteByte('.')
+			}
+			writeJSONPathName(&buf, seg.selection)
+		case descent:
+			buf.WriteString("..")
+		}
+		descent = false
+
+		if seg.key == "" {
+			continue
+		}
+		switch {
+		case seg.filter != nil:
+			return "", fmt.Errorf("goe el: expression %q has no JSONPath equivalent: %s: filter", expr, seg)
+		case seg.key == "*":
+			buf.WriteString("[*]")
+		case seg.elements != nil:
+			buf.WriteByte('[')
+			buf.WriteString(seg.elements.String())
+			buf.WriteByte(']')
+		case seg.index >= 0:
+			fmt.Fprintf(&buf, "[%d]", seg.index)
+		default:
+			name, err := seg.keyName()
+			if err != nil {
+				return "", fmt.Errorf("goe el: expression %q has no JSONPath equivalent: %w", expr, err)
+			}
+			buf.WriteString("['")
+			buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(name, `\`, `\\`), `'`, `\'`))
+			buf.WriteString("']")
+		}
+	}
+	if descent {
+		buf.WriteString("..*")
+	}
+	return buf.String(), nil
+}
+
+// writeJSONPathName writes the child selection of name.
+func writeJSONPathName(buf *strings.Builder, name string) {
+	plain := name != "" && name != "*"
+	for i := 0; i < len(name); i++ {
+		if !isNameByte(name[i]) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		buf.WriteByte('.')
+		buf.WriteString(name)
+		return
+	}
+	buf.WriteString("['")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(name, `\`, `\\`), `'`, `\'`))
+	buf.WriteString("']")
+}
+
+// String returns the range notation in decimals.
+func (r *elementRange) String() string {
+	if r.single {
+		return strconv.Itoa(r.start)
+	}
+	var buf strings.Builder
+	if r.hasStart {
+		buf.WriteString(strconv.Itoa(r.start))
+	}
+	buf.WriteByte(':')
+	if r.hasEnd {
+		buf.WriteString(strconv.Itoa(r.end))
+	}
+	if r.step != 1 {
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(r.step))
+	}
+	return buf.String()
+}
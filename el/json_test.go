@@ -0,0 +1,177 @@
+package el
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestJSONPointerConversion(t *testing.T) {
+	tests := []struct{ ptr, expr string }{
+		{"", "/"},
+		{"/", `/@json:""`},
+		{"/nodes/7/cache/ttl", "/@json:nodes/@json:7/@json:cache/@json:ttl"},
+		{"/a~1b/m~0n", `/@json:"a/b"/@json:m~n`},
+		{"/*/c[d]", `/@json:"*"/@json:"c[d]"`},
+		{"#/a%20b", `/@json:"a b"`},
+		{"/m/a\tb", `/@json:m/@json:"a\tb"`},
+		{"/f(x)", `/@json:"f(x)"`},
+		{"/a==b/c&&d", `/@json:"a==b"/@json:"c&&d"`},
+	}
+	for _, test := range tests {
+		got, err := FromJSONPointer(test.ptr)
+		if err != nil {
+			t.Errorf("%q: %s", test.ptr, err)
+			continue
+		}
+		if got != test.expr {
+			t.Errorf("%q: got %q, want %q", test.ptr, got, test.expr)
+		}
+		if _, err := Compile(got); err != nil {
+			t.Errorf("%q: %s", test.ptr, err)
+		}
+
+		if strings.HasPrefix(test.ptr, "#") {
+			continue
+		}
+		back, err := ToJSONPointer(got)
+		if err != nil {
+			t.Errorf("%q: %s", got, err)
+		} else if back != test.ptr {
+			t.Errorf("%q: got %q back, want %q", got, back, test.ptr)
+		}
+	}
+
+	for _, ptr := range []string{"a", "/~", "/~2"} {
+		if _, err := FromJSONPointer(ptr); err == nil {
+			t.Errorf("%q: no error", ptr)
+		}
+	}
+}
+
+func TestToJSONPointer(t *testing.T) {
+	tests := []struct{ expr, ptr string }{
+		{"/Nodes[7]/Cache/TTL", "/Nodes/7/Cache/TTL"},
+		{`/Labels["a/b~"]`, "/Labels/a~1b~0"},
+		{"/.['p']/.[0x10]", "/112/16"},
+	}
+	for _, test := range tests {
+		got, err := ToJSONPointer(test.expr)
+		if err != nil {
+			t.Errorf("%q: %s", test.expr, err)
+		} else if got != test.ptr {
+			t.Errorf("%q: got %q, want %q", test.expr, got, test.ptr)
+		}
+	}
+
+	for _, expr := range []string{"/*", "/A[*]", "/**/A", "/A[-1]", "/A[1:2]", "/A[?B]", "/@yaml:a", "/@json:*", "/URL/Host()"} {
+		if got, err := ToJSONPointer(expr); err == nil {
+			t.Errorf("%q: got %q, want error", expr, got)
+		}
+	}
+}
+
+func TestJSONPathConversion(t *testing.T) {
+	tests := []struct{ path, expr, back string }{
+		{"$", "/", "$"},
+		{"$.nodes[7].cache.ttl", "/@json:nodes/@json:7/@json:cache/@json:ttl", "$.nodes.7.cache.ttl"},
+		{"$['a.b']['c\\'d']", `/@json:a.b/@json:c'd`, "$['a.b']['c\\'d']"},
+		{`$["x/y"]`, `/@json:"x/y"`, "$['x/y']"},
+		{"$.m['a b']", `/@json:m/@json:"a b"`, "$.m['a b']"},
+		{"$['f(x)']", `/@json:"f(x)"`, "$['f(x)']"},
+		{"$.store.*", "/@json:store/@json:*", "$.store.*"},
+		{"$.store[*]", "/@json:store/@json:*", "$.store.*"},
+		{"$..price", "/**/@json:price", "$..price"},
+		{"$..*", "/**/@json:*", "$..*"},
+		{"$..[0]", "/**/@json:0", "$..0"},
+		{"$.list[-1]", "/@json:list/.[-1]", "$.list[-1]"},
+		{"$.list[1:5:2]", "/@json:list/.[1:5:2]", "$.list[1:5:2]"},
+		{"$.list[?(@.price < 10 && @.tag == 'x')]", `/@json:list/.[?(@json:price < 10 && @json:tag == "x")]`, ""},
+		{"$.list[?(@ > 2)]", "/@json:list/.[?(. > 2)]", ""},
+		{"$.list[?(@.a['b'][0])]", "/@json:list/.[?(@json:a/@json:b/@json:0)]", ""},
+	}
+	for _, test := range tests {
+		got, err := FromJSONPath(test.path)
+		if err != nil {
+			t.Errorf("%q: %s", test.path, err)
+			continue
+		}
+		if got != test.expr {
+			t.Errorf("%q: got %q, want %q", test.path, got, test.expr)
+		}
+		if _, err := Compile(got); err != nil {
+			t.Errorf("%q: %s", test.path, err)
+		}
+
+		if test.back == "" {
+			continue
+		}
+		back, err := ToJSONPath(got)
+		if err != nil {
+			t.Errorf("%q: %s", got, err)
+		} else if back != test.back {
+			t.Errorf("%q: got %q back, want %q", got, back, test.back)
+		}
+	}
+
+	for _, path := range []string{"", "x", "$.", "$[]", "$[0,1]", "$[?(@.a == $.b)]", "$['x]"} {
+		if got, err := FromJSONPath(path); err == nil {
+			t.Errorf("%q: got %q, want error", path, got)
+		}
+	}
+}
+
+func TestToJSONPath(t *testing.T) {
+	tests := []struct{ expr, path string }{
+		{"/Nodes[7]/Cache/TTL", "$.Nodes[7].Cache.TTL"},
+		{`/Labels["x"]/*`, "$.Labels['x'].*"},
+		{"/Services/**/Timeout", "$.Services..Timeout"},
+		{"/Services/**", "$.Services..*"},
+		{"/**/.[0]", "$..[0]"},
+		{"/S[::-1]", "$.S[::-1]"},
+	}
+	for _, test := range tests {
+		got, err := ToJSONPath(test.expr)
+		if err != nil {
+			t.Errorf("%q: %s", test.expr, err)
+		} else if got != test.path {
+			t.Errorf("%q: got %q, want %q", test.expr, got, test.path)
+		}
+	}
+
+	for _, expr := range []string{"/A[?B]", "/@yaml:a", "/URL/Host()"} {
+		if got, err := ToJSONPath(expr); err == nil {
+			t.Errorf("%q: got %q, want error", expr, got)
+		}
+	}
+}
+
+func TestJSONEvaluation(t *testing.T) {
+	type cache struct {
+		TTL int `json:"ttl"`
+	}
+	type node struct {
+		Name  string `json:"name"`
+		Cache *cache `json:"cache,omitempty"`
+	}
+	root := &struct {
+		Nodes []node `json:"nodes"`
+	}{}
+
+	ptr, err := CompileJSONPointer("/nodes/1/cache/ttl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := ptr.Assign(root, 60); n != 1 {
+		t.Fatalf("got %d updates, want 1", n)
+	}
+	root.Nodes[0].Name = "a"
+	root.Nodes[1].Name = "b"
+
+	path, err := CompileJSONPath("$.nodes[?(@.cache.ttl >= 60)].name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "JSONPath", path.Strings(root), []string{"b"})
+}
@@ -0,0 +1,188 @@
+package el
+
+import (
+	"fmt"
+)
+
+// Limits bounds the evaluation of expressions, e.g., for expressions from
+// untrusted sources. A zero value disables the respective bound.
+//
+// Evaluation stops once a budget runs out, with no result, as if the
+// expression did not match. Modifications on multiple matches may apply to
+// some of them before the budget runs out. Check has no bounds.
+//
+// Method invocations run arbitrary code, including any side effects, which no
+// budget can bound. Compile rejects them unless AllowCalls is set. Limits do
+// not restrict the content available otherwise. Use a Policy for that.
+type Limits struct {
+	// MaxLength is the maximum number of bytes in an expression.
+	MaxLength int
+	// MaxDepth is the maximum number of segments in a path. Paths in
+	// filters count on top of the segments up to and including the one with
+	// the filter. Paths in expressions with operators count on their own.
+	MaxDepth int
+	// MaxResults is the maximum number of values selected in an
+	// evaluation, counted per segment, such that intermediate selections,
+	// and those of paths in filters and operators, count too.
+	MaxResults int
+	// MaxGrowth is the maximum number of elements added to slices in an
+	// evaluation, for indices and ranges beyond the length.
+	MaxGrowth int
+
+	// AllowCalls permits method invocations.
+	AllowCalls bool
+}
+
+// Compile is like the package-level function, with l applied on expr and on
+// each evaluation of the result.
+func (l Limits) Compile(expr string) (*Expr, error) {
+	if l.MaxLength > 0 && len(expr) > l.MaxLength {
+		return nil, fmt.Errorf("goe el: expression of %d bytes exceeds the maximum length of %d", len(expr), l.MaxLength)
+	}
+
+	x, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !l.AllowCalls {
+		paths := []relPath{x.path}
+		if x.x != nil {
+			paths = operandPaths(x.x)
+		}
+		for _, path := range paths {
+			if hasCall(path) {
+				return nil, fmt.Errorf("goe el: expression %q has a method invocation, which the limits do not allow", expr)
+			}
+		}
+	}
+
+	if l.MaxDepth > 0 {
+		var depth int
+		if x.x != nil {
+			depth = operandDepth(x.x)
+		} else {
+			depth = pathDepth(x.path)
+		}
+		if depth > l.MaxDepth {
+			return nil, fmt.Errorf("goe el: expression %q has a path depth of %d, which exceeds the maximum of %d", expr, depth, l.MaxDepth)
+		}
+	}
+
+	x.limits = &l
+	return x, nil
+}
+
+// pathDepth returns the number of segments in path, including those of paths
+// in filters.
+func pathDepth(path []segment) int {
+	depth := len(path)
+	for i := range path {
+		if f := path[i].filter; f != nil {
+			if n := i + 1 + operandDepth(f); n > depth {
+				depth = n
+			}
+		}
+	}
+	return depth
+}
+
+// operandDepth returns the greatest pathDepth in x.
+func operandDepth(x operand) int {
+	var depth int
+	for _, path := range operandPaths(x) {
+		if n := pathDepth(path); n > depth {
+			depth = n
+		}
+	}
+	return depth
+}
+
+// constrain returns ev with a budget conform the limits of x, and with the
+// policy of x, if any. A nil ev gets a read-only evaluation for the purpose.
+func (x *Expr) constrain(ev *evaluation) *evaluation {
+	if x.limits == nil && x.policy == nil {
+		return ev
+	}
+	if ev == nil {
+		ev = &evaluation{query: true}
+	}
+	ev.budget = &budget{limits: x.limits}
+	ev.policy = x.policy
+	return ev
+}
+
+// budget is the resource consumption of an evaluation, with the end result.
+type budget struct {
+	limits *Limits
+	// results is the number of values selected so far.
+	results int
+	// growth is the number of slice elements added so far.
+	growth int
+	// out marks a budget which ran out.
+	out bool
+	// denied marks a policy violation.
+	denied bool
+}
+
+// inspection is a read-only evaluation without budget.
+var inspection = &evaluation{query: true}
+
+// reads returns a read-only evaluation with the budget of ev, if any.
+func (ev *evaluation) reads() *evaluation {
+	switch {
+	case ev == nil || ev.budget == nil:
+		return inspection
+	case ev.query && !ev.trace:
+		return ev
+	}
+	return &evaluation{query: true, budget: ev.budget, policy: ev.policy}
+}
+
+// voided returns whether the evaluation has no result, because its budget ran
+// out, or because of its policy.
+func (ev *evaluation) voided() bool {
+	return ev != nil && ev.budget != nil && (ev.budget.out || ev.budget.denied)
+}
+
+// fits returns whether n more values fit the budget, if any. The budget runs
+// out otherwise.
+func (ev *evaluation) fits(n int) bool {
+	if ev == nil || ev.budget == nil {
+		return true
+	}
+	b := ev.budget
+	if b.limits != nil && b.limits.MaxResults > 0 && b.results+n > b.limits.MaxResults {
+		b.out = true
+	}
+	return !b.out && !b.denied
+}
+
+// spend claims n values from the budget, if any. The return is false when the
+// budget ran out.
+func (ev *evaluation) spend(n int) bool {
+	if !ev.fits(n) {
+		return false
+	}
+	if ev != nil && ev.budget != nil {
+		ev.budget.results += n
+	}
+	return true
+}
+
+// grows claims n slice elements from the budget, if any. The return is false
+// when the budget ran out.
+func (ev *evaluation) grows(n int) bool {
+	if ev == nil || ev.budget == nil {
+		return true
+	}
+	b := ev.budget
+	if b.limits != nil && b.limits.MaxGrowth > 0 && b.growth+n > b.limits.MaxGrowth {
+		b.out = true
+	}
+	if b.out || b.denied {
+		return false
+	}
+	b.growth += n
+	return true
+}
@@ -0,0 +1,171 @@
+package el
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestLimitsCompile(t *testing.T) {
+	golden := []struct {
+		limits Limits
+		expr   string
+		ok     bool
+	}{
+		{Limits{}, "/Nodes[0]/Host/Len()/X", false},
+		{Limits{AllowCalls: true}, "/Nodes[0]/Host/Len()/X", true},
+		{Limits{}, "/Nodes[?(Host/Len() > 0)]", false},
+		{Limits{}, "len(/Nodes[*]) + /Name/Len()", false},
+		{Limits{MaxLength: 5}, "/Name", true},
+		{Limits{MaxLength: 4}, "/Name", false},
+		{Limits{MaxLength: 4}, "/Na(", false},
+		{Limits{MaxDepth: 2}, "/Cache/TTL", true},
+		{Limits{MaxDepth: 2, AllowCalls: true}, "/Nodes[0]/Host/Len()", false},
+		{Limits{MaxDepth: 2}, "/Nodes[?(Load > 40)]/Host", true},
+		{Limits{MaxDepth: 2}, "/Nodes[?(/Cache/TTL)]/Host", false},
+		{Limits{MaxDepth: 2}, "len(/Nodes[*]/Host) + 1", true},
+		{Limits{MaxDepth: 2, AllowCalls: true}, "len(/Nodes[*]/Host/Len()) + 1", false},
+		{Limits{MaxDepth: 2, AllowCalls: true}, "/Name == 'x' ? 1 : count(/Nodes[*]/Host/Len())", false},
+	}
+	for _, gold := range golden {
+		x, err := gold.limits.Compile(gold.expr)
+		switch {
+		case gold.ok && err != nil:
+			t.Errorf("%+v: compile %q error: %s", gold.limits, gold.expr, err)
+		case !gold.ok && err == nil:
+			t.Errorf("%+v: compile %q got no error", gold.limits, gold.expr)
+		case !gold.ok && !strings.HasPrefix(err.Error(), "goe el: "):
+			t.Errorf("%+v: compile %q got error %q, want package prefix", gold.limits, gold.expr, err)
+		case gold.ok && x == nil:
+			t.Errorf("%+v: compile %q got nil", gold.limits, gold.expr)
+		}
+	}
+}
+
+func TestLimitsResults(t *testing.T) {
+	golden := []struct {
+		max  int
+		expr string
+		want []interface{}
+	}{
+		{0, "/Nodes[*]/Load", []interface{}{uint64(10), uint64(30), uint64(50), uint64(80)}},
+		{8, "/Nodes[*]/Load", []interface{}{uint64(10), uint64(30), uint64(50), uint64(80)}},
+		{7, "/Nodes[*]/Load", nil},
+		{2, "/Weights[*]", []interface{}{0.5, 1.5}},
+		{1, "/Weights[*]", nil},
+		{2, "/Cache/TTL", []interface{}{int64(90)}},
+		{1, "/Cache/TTL", nil},
+		{12, "/**", nil},
+		{8, "/Nodes[?(Load > 40)]/Host", []interface{}{"b", "d"}},
+		{7, "/Nodes[?(Load > 40)]/Host", nil},
+		{3, "len(/Nodes) + count(/Weights[*])", []interface{}{int64(6)}},
+		{2, "len(/Nodes) + count(/Weights[*])", nil},
+	}
+	for _, gold := range golden {
+		x, err := Limits{MaxResults: gold.max}.Compile(gold.expr)
+		if err != nil {
+			t.Fatalf("compile %q error: %s", gold.expr, err)
+		}
+		got := x.Any(testCluster)
+		if gold.want == nil && got != nil {
+			t.Errorf("%q with %d results max got %v, want none", gold.expr, gold.max, got)
+			continue
+		}
+		if gold.want != nil {
+			// map order is random
+			sort.Slice(got, func(i, j int) bool { return fmt.Sprint(got[i]) < fmt.Sprint(got[j]) })
+			verify.Values(t, gold.expr, got, gold.want)
+		}
+	}
+
+	// budget per evaluation
+	x, err := Limits{MaxResults: 4}.Compile("/Nodes[*]")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	for i := 0; i < 3; i++ {
+		if got := x.Any(testCluster); len(got) != 4 {
+			t.Errorf("evaluation %d got %v, want 4 nodes", i, got)
+		}
+	}
+	if got := x.Locate(testCluster); len(got) != 4 {
+		t.Errorf("located %v, want 4 nodes", got)
+	}
+	x, err = Limits{MaxResults: 3}.Compile("/Nodes[*]")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	if got := x.Locate(testCluster); got != nil {
+		t.Errorf("located %v, want none", got)
+	}
+}
+
+func TestLimitsGrowth(t *testing.T) {
+	x, err := Limits{MaxGrowth: 10}.Compile("/S[14]")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	n := &Node{S: []interface{}{1, 2, 3, 4, 5}}
+	if got := x.Assign(n, 99); got != 1 {
+		t.Errorf("assign within growth limit got %d, want 1", got)
+	}
+	if len(n.S) != 15 || n.S[14] != 99 {
+		t.Errorf("got elements %v, want 15 with 99 last", n.S)
+	}
+
+	x, err = Limits{MaxGrowth: 10}.Compile("/S[2147483647]")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	n = &Node{S: []interface{}{1, 2, 3, 4, 5}}
+	if got := x.Assign(n, 99); got != 0 {
+		t.Errorf("assign beyond growth limit got %d, want 0", got)
+	}
+	if len(n.S) != 5 {
+		t.Errorf("got %d elements, want 5 untouched", len(n.S))
+	}
+
+	x, err = Limits{MaxGrowth: 10}.Compile("/S[0:20]")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	if got := x.Assign(n, 7); got != 0 {
+		t.Errorf("assign range beyond growth limit got %d, want 0", got)
+	}
+	if len(n.S) != 5 {
+		t.Errorf("got %d elements, want 5 untouched", len(n.S))
+	}
+}
+
+func TestLimitsDelete(t *testing.T) {
+	x, err := Limits{MaxResults: 6}.Compile(`/**/Tags[?(. == "y")]`)
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	sessions := []*Session{
+		{User: "a", Tags: []string{"x", "y"}},
+		{User: "b", Tags: []string{"y"}},
+	}
+	if got := x.Delete(&sessions); got != 0 {
+		t.Errorf("delete beyond result limit got %d, want 0", got)
+	}
+	verify.Values(t, "untouched", sessions, []*Session{
+		{User: "a", Tags: []string{"x", "y"}},
+		{User: "b", Tags: []string{"y"}},
+	})
+
+	x, err = Limits{MaxResults: 100}.Compile(`/**/Tags[?(. == "y")]`)
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	if got := x.Delete(&sessions); got != 2 {
+		t.Errorf("delete within result limit got %d, want 2", got)
+	}
+	verify.Values(t, "deleted", sessions, []*Session{
+		{User: "a", Tags: []string{"x"}},
+		{User: "b", Tags: []string{}},
+	})
+}
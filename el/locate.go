@@ -0,0 +1,234 @@
+package el
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Match is a value with its location.
+type Match struct {
+	// Path is the canonical expression of the location, without any
+	// wildcards, filters, ranges, tag selections or recursive descents,
+	// e.g., `/Report/Stats["I\x2fO"]/warn[3]`.
+	Path string
+	// Value is the content, conform Any.
+	Value interface{}
+}
+
+// Locate returns the evaluation result with the location of each value.
+// Map entries from wildcards, filters and recursive descents are in order of
+// their key. Keys without a literal notation show as a wildcard in the path.
+func Locate(expr string, root interface{}) []Match {
+	path, err := parsePath(expr)
+	if err != nil {
+		return nil
+	}
+	return locate(path, root, nil)
+}
+
+// Locate is like the package-level function with the same name.
+func (x *Expr) Locate(root interface{}) []Match {
+	if x.x != nil {
+		return nil
+	}
+	ev := x.constrain(nil)
+	if !x.permits(ev) {
+		return nil
+	}
+	return locate(x.path, root, ev)
+}
+
+// located is a value with its canonical path.
+type located struct {
+	path  string
+	keyed bool // path ends with a key selection
+	v     reflect.Value
+}
+
+// field returns the location of a struct field with name in l.
+func (l located) field(name string, v reflect.Value) located {
+	return located{path: l.path + "/" + name, v: v}
+}
+
+// element returns the location of an element with number i in l.
+func (l located) element(i int, v reflect.Value) located {
+	return l.key(strconv.Itoa(i), v)
+}
+
+// key returns the location of key selection literal in l.
+func (l located) key(literal string, v reflect.Value) located {
+	path := l.path
+	if path == "" || l.keyed {
+		path += "/."
+	}
+	return located{path: path + "[" + literal + "]", keyed: true, v: v}
+}
+
+func locate(path []segment, root interface{}, ev *evaluation) []Match {
+	var matches []Match
+	for _, l := range locateValues(path, reflect.ValueOf(root), ev) {
+		x := asInterface(follow(ev.conceal(l.v), false))
+		if x == nil {
+			continue
+		}
+		if l.path == "" {
+			l.path = "/"
+		}
+		matches = append(matches, Match{Path: l.path, Value: x})
+	}
+	return matches
+}
+
+// locateValues returns the evaluation result of path on root as is, i.e.,
+// without following pointers and interfaces at the end. The evaluation is
+// read-only, with ev for the budget and the policy, if any.
+func locateValues(path []segment, root reflect.Value, ev *evaluation) []located {
+	if ev == nil {
+		ev = &evaluation{query: true}
+	}
+	ev.trace = true
+
+	track := []located{{v: root}}
+	values := []reflect.Value{root}
+	for i := range path {
+		if len(values) == 0 {
+			return nil
+		}
+		seg := &path[i]
+		values = ev.selects(values, seg)
+		if !ev.spend(len(values)) {
+			return nil
+		}
+		values = ev.leaves(values, seg)
+
+		next := make([]located, len(values))
+		for j, v := range values {
+			h := ev.hops[j]
+			next[j] = track[h.from].at(h.rel, v)
+		}
+		track = next
+	}
+	return track
+}
+
+// at returns the location rel, relative to l, with value v.
+func (l located) at(rel string, v reflect.Value) located {
+	path := l.path
+	if path == "" && strings.HasPrefix(rel, "[") {
+		path = "/."
+	}
+	return located{
+		path:  joinLocation(path, rel),
+		keyed: strings.HasSuffix(rel, "]") || rel == "" && l.keyed,
+		v:     v,
+	}
+}
+
+// hop is the location of a selected value, relative to the value in the track
+// it came from.
+type hop struct {
+	from int    // index in the track
+	rel  string // path notation, e.g., "/Name", "[2]" or "/Tags[0]/.[1]"
+}
+
+// tracing returns whether ev records hops and reasons.
+func (ev *evaluation) tracing() bool {
+	return ev != nil && ev.trace
+}
+
+// hop records the location rel of a value selected from the track at index
+// from, when tracing.
+func (ev *evaluation) hop(from int, rel string) {
+	if ev.tracing() {
+		ev.hops = append(ev.hops, hop{from, rel})
+	}
+}
+
+// miss records the reason why the track at index from has no selection, when
+// tracing.
+func (ev *evaluation) miss(from int, reason string) {
+	if ev.tracing() {
+		if ev.reasons == nil {
+			ev.reasons = make(map[int]string)
+		}
+		ev.reasons[from] = reason
+	}
+}
+
+// joinLocation returns relative location b on relative location a. A key
+// selection after another key selection needs a "." selection in between.
+func joinLocation(a, b string) string {
+	if strings.HasSuffix(a, "]") && strings.HasPrefix(b, "[") {
+		return a + "/." + b
+	}
+	return a + b
+}
+
+// keyLocation returns the location of key selection literal on rel.
+func keyLocation(rel, literal string) string {
+	return joinLocation(rel, "["+literal+"]")
+}
+
+// fieldLocation returns the location of the field with index in struct type
+// t, with a component for each embedded struct on the way.
+func fieldLocation(t reflect.Type, index []int) string {
+	var buf strings.Builder
+	for _, x := range index {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		f := t.Field(x)
+		buf.WriteByte('/')
+		buf.WriteString(f.Name)
+		t = f.Type
+	}
+	return buf.String()
+}
+
+// keyLiteral returns the notation of map key k for a key selection. Slashes in
+// strings are escaped, such that paths can be split on them without parsing.
+func keyLiteral(k reflect.Value) string {
+	switch k.Kind() {
+	case reflect.String:
+		return strings.ReplaceAll(strconv.Quote(k.String()), "/", `\x2f`)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(k.Float(), 'f', -1, k.Type().Bits())
+	case reflect.Bool:
+		return strconv.FormatBool(k.Bool())
+	}
+	return "*"
+}
+
+// sortKeys returns the map keys in order.
+func sortKeys(keys []reflect.Value) []reflect.Value {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		for a.Kind() == reflect.Interface && !a.IsNil() {
+			a = a.Elem()
+		}
+		for b.Kind() == reflect.Interface && !b.IsNil() {
+			b = b.Elem()
+		}
+		if a.Kind() == b.Kind() {
+			switch kindClass(a.Kind()) {
+			case reflect.Int:
+				return a.Int() < b.Int()
+			case reflect.Uint:
+				return a.Uint() < b.Uint()
+			case reflect.Float64:
+				return a.Float() < b.Float()
+			case reflect.String:
+				return a.String() < b.String()
+			}
+		}
+		return fmt.Sprint(a) < fmt.Sprint(b)
+	})
+	return keys
+}
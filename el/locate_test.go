@@ -0,0 +1,75 @@
+package el
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestLocate(t *testing.T) {
+	root := &struct {
+		Report struct {
+			Stats map[string]struct {
+				Warn []string `json:"warn"`
+			}
+			Codes map[int8]float32
+		}
+		Grid  [][]int
+		Cache *Cache
+	}{
+		Grid:  [][]int{{1, 2}, {3}},
+		Cache: &Cache{TTL: 9, Meta: &Meta{Owner: "ops"}},
+	}
+	root.Report.Stats = map[string]struct {
+		Warn []string `json:"warn"`
+	}{
+		"I/O": {Warn: []string{"slow", "lost"}},
+		"CPU": {Warn: []string{"hot"}},
+	}
+	root.Report.Codes = map[int8]float32{-1: 0.5, 7: 2}
+
+	tests := []struct {
+		expr string
+		want []Match
+	}{
+		{"/Report/Codes[*]", []Match{
+			{`/Report/Codes[-1]`, 0.5},
+			{`/Report/Codes[7]`, 2.0},
+		}},
+		{"/Report/Stats[*]/Warn[*]", []Match{
+			{`/Report/Stats["CPU"]/Warn[0]`, "hot"},
+			{`/Report/Stats["I\x2fO"]/Warn[0]`, "slow"},
+			{`/Report/Stats["I\x2fO"]/Warn[1]`, "lost"},
+		}},
+		{`/Report/Stats/@json:"I/O"/@json:warn[-1]`, []Match{
+			{`/Report/Stats["I\x2fO"]/Warn[1]`, "lost"},
+		}},
+		{`/Report/Stats[?Warn[*] == "hot"]/Warn[::-1]`, []Match{
+			{`/Report/Stats["CPU"]/Warn[0]`, "hot"},
+		}},
+		{"/Grid[*][?. > 1]", nil},
+		{"/Grid[*]/.[?. > 1]", []Match{
+			{"/Grid[0]/.[1]", int64(2)},
+			{"/Grid[1]/.[0]", int64(3)},
+		}},
+		{"/Cache/@json:owner", []Match{{"/Cache/Meta/Owner", "ops"}}},
+		{"/Cache/**/TTL", []Match{{"/Cache/TTL", int64(9)}}},
+		// promoted field and its origin
+		{"/**/Owner", []Match{{"/Cache/Meta/Owner", "ops"}, {"/Cache/Meta/Owner", "ops"}}},
+		{"/Grid/.[1]/.", []Match{{"/Grid[1]", []int{3}}}},
+		{"/Mis", nil},
+	}
+	for _, test := range tests {
+		got := Locate(test.expr, root)
+		verify.Values(t, test.expr, got, test.want)
+
+		for _, m := range got {
+			if v := Any(m.Path, root); len(v) != 1 {
+				t.Errorf("%s: path %q has %d values", test.expr, m.Path, len(v))
+			}
+		}
+	}
+
+	verify.Values(t, "root", Locate("/", 42), []Match{{"/", int64(42)}})
+	verify.Values(t, "root element", MustCompile("/.[1]").Locate([]string{"a", "b"}), []Match{{"/.[1]", "b"}})
+}
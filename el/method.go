@@ -0,0 +1,152 @@
+package el
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// splitArgs returns the comma-separated literals in s.
+func splitArgs(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var args []string
+	offset := 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			switch s[i] {
+			case '"', '\'', '`':
+				end, err := literalEnd(s, i)
+				if err != nil {
+					return nil, err
+				}
+				i = end - 1
+				continue
+			case ',':
+				break
+			default:
+				continue
+			}
+		}
+
+		arg := strings.TrimSpace(s[offset:i])
+		if arg == "" {
+			return nil, fmt.Errorf("goe el: arguments %q have an empty entry", s)
+		}
+		args = append(args, arg)
+		offset = i + 1
+	}
+	return args, nil
+}
+
+// followMethod returns the results of the invocation of seg on each of track.
+func followMethod(track []reflect.Value, seg *segment, ev *evaluation) []reflect.Value {
+	writeIndex := 0
+	for from, v := range track {
+		r, err := seg.invoke(v)
+		if err != nil {
+			ev.miss(from, err.Error())
+			continue
+		}
+		track[writeIndex] = r
+		writeIndex++
+		ev.hop(from, "/"+seg.selectionString())
+	}
+	return track[:writeIndex]
+}
+
+// invoke returns the result of the method call of seg on v. The error tells
+// why there is no result.
+func (seg *segment) invoke(v reflect.Value) (reflect.Value, error) {
+	f := follow(v, false)
+	if !f.IsValid() {
+		return reflect.Value{}, errors.New(absentReason(v))
+	}
+	if !f.CanInterface() {
+		return reflect.Value{}, fmt.Errorf("type %s is not exported", f.Type())
+	}
+
+	// pointer receivers included
+	m := f.MethodByName(seg.selection)
+	if f.CanAddr() {
+		m = f.Addr().MethodByName(seg.selection)
+	}
+	if !m.IsValid() {
+		return reflect.Value{}, fmt.Errorf("type %s has no method %s", f.Type(), seg.selection)
+	}
+	t := m.Type()
+	if err := seg.checkSignature(t); err != nil {
+		return reflect.Value{}, fmt.Errorf("method %s of type %s %w", seg.selection, f.Type(), err)
+	}
+
+	args := make([]reflect.Value, len(seg.args))
+	for i, s := range seg.args {
+		p := parseLiteral(s, t.In(i))
+		if p == nil {
+			return reflect.Value{}, fmt.Errorf("argument %s does not fit parameter type %s of method %s", s, t.In(i), seg.selection)
+		}
+		args[i] = *p
+	}
+
+	out, err := callSafe(m, args)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("method %s of type %s %w", seg.selection, f.Type(), err)
+	}
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("method %s of type %s returned error: %s", seg.selection, f.Type(), out[1].Interface())
+	}
+	return out[0], nil
+}
+
+// callSafe invokes m with args. A panic counts as an error.
+func callSafe(m reflect.Value, args []reflect.Value) (out []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panicked: %v", r)
+		}
+	}()
+	return m.Call(args), nil
+}
+
+// checkSignature verifies method type t, without the receiver, for seg.
+// Methods need one result other than error, optionally followed by an error.
+func (seg *segment) checkSignature(t reflect.Type) error {
+	switch {
+	case t.IsVariadic():
+		return errors.New("is variadic")
+	case t.NumIn() != len(seg.args):
+		return fmt.Errorf("takes %d arguments, not %d", t.NumIn(), len(seg.args))
+	case t.NumOut() == 0 || t.Out(0) == errorType:
+		break
+	case t.NumOut() == 1, t.NumOut() == 2 && t.Out(1) == errorType:
+		return nil
+	}
+	return errors.New("does not return one value, other than an error, optionally followed by an error")
+}
+
+// methodType returns the result type of the invocation of seg on t, with nil
+// for none. Pointer receivers are included.
+func (seg *segment) methodType(t reflect.Type) reflect.Type {
+	m, ok := reflect.PointerTo(t).MethodByName(seg.selection)
+	if !ok {
+		return nil
+	}
+	// drop the receiver
+	in := make([]reflect.Type, m.Type.NumIn()-1)
+	for i := range in {
+		in[i] = m.Type.In(i + 1)
+	}
+	out := make([]reflect.Type, m.Type.NumOut())
+	for i := range out {
+		out[i] = m.Type.Out(i)
+	}
+	if seg.checkSignature(reflect.FuncOf(in, out, m.Type.IsVariadic())) != nil {
+		return nil
+	}
+	return out[0]
+}
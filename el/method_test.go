@@ -0,0 +1,172 @@
+package el
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+type endpoint struct {
+	URL     *url.URL
+	Timeout time.Duration
+	Started time.Time
+	Tags    tagList
+	Inner   struct{ Tags tagList }
+}
+
+type tagList []string
+
+func (l tagList) Len() int { return len(l) }
+
+func (l *tagList) Last() string {
+	if len(*l) == 0 {
+		return ""
+	}
+	return (*l)[len(*l)-1]
+}
+
+func (l tagList) First() string { return l[0] }
+
+func (l *tagList) Reset() error {
+	*l = nil
+	return nil
+}
+
+func (l tagList) At(i int) (string, error) {
+	if i < 0 || i >= len(l) {
+		return "", errors.New("index out of range")
+	}
+	return l[i], nil
+}
+
+func (l tagList) Join(sep string, n uint8) string {
+	if int(n) < len(l) {
+		l = l[:n]
+	}
+	return strings.Join(l, sep)
+}
+
+func (l tagList) Each(f func(string)) {
+	for _, s := range l {
+		f(s)
+	}
+}
+
+var testEndpoint = &endpoint{
+	URL:     &url.URL{Scheme: "https", Host: "example.com:8443", Path: "/api", RawQuery: "q=1"},
+	Timeout: 90 * time.Second,
+	Started: time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC),
+	Tags:    tagList{"a", "b", "c"},
+}
+
+func TestMethods(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []interface{}
+	}{
+		{"/URL/Hostname()", []interface{}{"example.com"}},
+		{"/URL/Port()", []interface{}{"8443"}},
+		{`/URL/Query()["q"]/.[0]`, []interface{}{"1"}},
+		{"/Timeout/Seconds()", []interface{}{90.0}},
+		{"/Timeout/Round(60000000000)/Minutes()", []interface{}{2.0}},
+		{`/Started/Format("2006/01/02")`, []interface{}{"2001/02/03"}},
+		{"/Tags/Len()", []interface{}{int64(3)}},
+		{"/Tags/Last()", []interface{}{"c"}},
+		{"/Tags/At(1)", []interface{}{"b"}},
+		{"/Tags/At(7)", nil},
+		{`/Tags/Join(", ", 2)`, []interface{}{"a, b"}},
+		{`/Tags/Join("-",9)`, []interface{}{"a-b-c"}},
+		{"/Tags/Join()", nil},
+		{`/Tags/At("x")`, nil},
+		{"/Tags/Each()", nil},
+		{"/Tags/Absent()", nil},
+		{"/**/Len()", []interface{}{int64(3), int64(0)}},
+		{"/Inner/Tags/Last()", []interface{}{""}},
+		{"/Tags/First()", []interface{}{"a"}},
+		{"/Inner/Tags/First()", nil},
+		{"/Tags/Reset()", nil},
+	}
+	for _, test := range tests {
+		verify.Values(t, test.expr, Any(test.expr, testEndpoint), test.want)
+	}
+
+	if got, ok := Bool(`/URL/Hostname() == "example.com" && /Tags/Len() > 2`, testEndpoint); !ok || !got {
+		t.Errorf("expression got %t, %t", got, ok)
+	}
+	verify.Values(t, "filter", Strings(`/.[?Len() > 2]/Last()`, []tagList{{"x"}, {"p", "q", "r"}}), []string{"r"})
+	verify.Values(t, "tags", testEndpoint.Tags, tagList{"a", "b", "c"})
+
+	const want = "method First of type el.tagList panicked: runtime error: index out of range [0] with length 0"
+	r := Explain("/Inner/Tags/First()", testEndpoint)
+	verify.Values(t, "panic reasons", r.Steps[len(r.Steps)-1].Reasons, []string{want})
+}
+
+func TestMethodValueReceivers(t *testing.T) {
+	// not addressable
+	got := Any("/Last()", tagList{"x"})
+	if len(got) != 0 {
+		t.Errorf("got %v for pointer receiver on non-addressable value", got)
+	}
+	verify.Values(t, "value receiver", Any("/Len()", tagList{"x"}), []interface{}{int64(1)})
+}
+
+func TestMethodModification(t *testing.T) {
+	if n := Assign(testEndpoint, `/URL/Query()["q"]`, []string{"2"}); n != 0 {
+		t.Errorf("assign on invocation result got %d", n)
+	}
+	if n := Delete(testEndpoint, "/Tags/Len()"); n != 0 {
+		t.Errorf("delete on invocation got %d", n)
+	}
+	if n := Delete(testEndpoint, `/URL/Query()["q"]`); n != 0 {
+		t.Errorf("delete on invocation result got %d", n)
+	}
+	verify.Values(t, "tags", testEndpoint.Tags, tagList{"a", "b", "c"})
+}
+
+func TestMethodSyntax(t *testing.T) {
+	for _, expr := range []string{
+		"/Tags/len()",
+		"/Tags/()",
+		"/Tags/Len(",
+		"/Tags/Len()x",
+		"/Tags/Join(,)",
+		"/Tags[0]Len()",
+		"/Tags/Len()()",
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("%s: no compile error", expr)
+		}
+	}
+}
+
+func TestMethodCheck(t *testing.T) {
+	typ := reflect.TypeOf(endpoint{})
+	for _, expr := range []string{"/URL/Hostname()", "/Tags/Last()", "/Tags/At(2)", "/Timeout/Round(1)/Minutes()"} {
+		if err := Check(expr, typ); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, expr := range []string{"/URL/Host()", "/Tags/Each()", "/Tags/Reset()", "/Tags/At()", "/Tags/Len()/X"} {
+		if err := Check(expr, typ); err == nil {
+			t.Errorf("%s: no error", expr)
+		}
+	}
+}
+
+func TestMethodExplainAndLocate(t *testing.T) {
+	r := Explain("/Tags/At(5)", testEndpoint)
+	if r.Results != 0 || len(r.Steps) != 2 || len(r.Steps[1].Reasons) != 1 {
+		t.Fatalf("got report %s", r)
+	}
+	const want = "method At of type el.tagList returned error: index out of range"
+	if got := r.Steps[1].Reasons[0]; got != want {
+		t.Errorf("got reason %q, want %q", got, want)
+	}
+
+	verify.Values(t, "locate", Locate("/URL/Port()", testEndpoint), []Match{{Path: "/URL/Port()", Value: "8443"}})
+}
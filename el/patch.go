@@ -0,0 +1,455 @@
+package el
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MergePatch applies an RFC 7386 JSON Merge Patch onto the value root points
+// to. Object members select struct fields by their JSON name and map entries by
+// their key, like the "@json:" notation does. Null members delete their target
+// in the manner of Delete, and other values replace the target conform
+// encoding/json. Absent content on the way is instantiated like with Assign.
+//
+// The patch applies either as a whole or not at all. Root is left as it was
+// when the error is not nil. Otherwise, pointers, maps and slices in root stay
+// in place with their content updated, unless the patch replaces them.
+func MergePatch(root interface{}, patch []byte) error {
+	if !json.Valid(patch) {
+		return errors.New("goe el: merge patch is not valid JSON")
+	}
+	return atomic(root, func(root reflect.Value) error {
+		ev := &evaluation{build: true}
+		err := mergeValue(root, patch, "", ev)
+		ev.finish()
+		if err != nil {
+			return fmt.Errorf("goe el: merge patch: %w", err)
+		}
+		return nil
+	})
+}
+
+// mergeValue applies patch onto v, with ptr as the JSON Pointer of v.
+func mergeValue(v reflect.Value, patch json.RawMessage, ptr string, ev *evaluation) error {
+	if !isJSONObject(patch) {
+		return decodeValue(v, patch, ptr)
+	}
+
+	f := follow(v, true)
+	switch f.Kind() {
+	case reflect.Struct, reflect.Map:
+		break
+	default:
+		if v.Kind() != reflect.Interface {
+			return fmt.Errorf("object at %q does not apply to type %s", ptr, v.Type())
+		}
+		// content is not an object; replace with patch
+		var x interface{}
+		if err := json.Unmarshal(patch, &x); err != nil {
+			return fmt.Errorf("JSON Pointer %q: %w", ptr, err)
+		}
+		return setValue(v, reflect.ValueOf(withoutNulls(x)), ptr)
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil {
+		return fmt.Errorf("JSON Pointer %q: %w", ptr, err)
+	}
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		memberPtr := ptr + "/" + escapeJSONPointer(name)
+		seg, err := wireSegment(name)
+		if err != nil {
+			return err
+		}
+
+		if string(members[name]) == "null" {
+			deleteSelection(f, seg, nil)
+			continue
+		}
+		targets := followTagged([]reflect.Value{f}, seg, ev)
+		if len(targets) == 0 {
+			return fmt.Errorf("JSON Pointer %q does not apply to type %s", memberPtr, f.Type())
+		}
+		if err := mergeValue(targets[0], members[name], memberPtr, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withoutNulls returns x with all null members removed recursively.
+func withoutNulls(x interface{}) interface{} {
+	if m, ok := x.(map[string]interface{}); ok {
+		for name, v := range m {
+			if v == nil {
+				delete(m, name)
+			} else {
+				m[name] = withoutNulls(v)
+			}
+		}
+	}
+	return x
+}
+
+// ApplyPatch applies an RFC 6902 JSON Patch onto the value root points to.
+// The JSON Pointers of operations translate with FromJSONPointer. All of the
+// operations "add", "remove", "replace", "move", "copy" and "test" are
+// supported. Values apply conform encoding/json. Absent content on the way to
+// an addition is instantiated like with Assign. Removal follows the rules of
+// Delete, and it requires the content to be present, just like replacement
+// does.
+//
+// The patch applies either as a whole or not at all. Root is left as it was
+// when the error is not nil. Otherwise, pointers, maps and slices in root stay
+// in place with their content updated, unless the patch replaces them.
+func ApplyPatch(root interface{}, patch []byte) error {
+	var ops []struct {
+		Op    string          `json:"op"`
+		Path  *string         `json:"path"`
+		From  *string         `json:"from"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return fmt.Errorf("goe el: JSON Patch: %w", err)
+	}
+
+	return atomic(root, func(root reflect.Value) error {
+		for i, op := range ops {
+			if op.Path == nil {
+				return fmt.Errorf("goe el: JSON Patch operation %d has no path", i)
+			}
+			switch op.Op {
+			case "move", "copy":
+				if op.From == nil {
+					return fmt.Errorf("goe el: JSON Patch operation %d has no from", i)
+				}
+			case "add", "replace", "test":
+				if op.Value == nil {
+					return fmt.Errorf("goe el: JSON Patch operation %d has no value", i)
+				}
+			}
+
+			var err error
+			switch op.Op {
+			case "add":
+				err = patchAdd(root, *op.Path, op.Value)
+			case "remove":
+				err = patchRemove(root, *op.Path)
+			case "replace":
+				err = patchReplace(root, *op.Path, op.Value)
+			case "move":
+				err = patchMove(root, *op.From, *op.Path)
+			case "copy":
+				var raw json.RawMessage
+				raw, err = patchGet(root, *op.From)
+				if err == nil {
+					err = patchAdd(root, *op.Path, raw)
+				}
+			case "test":
+				err = patchTest(root, *op.Path, op.Value)
+			default:
+				err = fmt.Errorf("unknown operation %q", op.Op)
+			}
+			if err != nil {
+				return fmt.Errorf("goe el: JSON Patch operation %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+}
+
+// patchPath returns the GoEL path of JSON Pointer ptr.
+func patchPath(ptr string) ([]segment, error) {
+	expr, err := fromJSONPointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	path, err := parsePath(expr)
+	if err != nil {
+		return nil, unprefixed(err)
+	}
+	return path, nil
+}
+
+// patchParents returns the content which holds the last segment of path, if
+// any, on root. Pointers and interfaces are not followed.
+func patchParents(root reflect.Value, path []segment, ev *evaluation) []reflect.Value {
+	if len(path) < 2 {
+		return []reflect.Value{root}
+	}
+	track := resolveValue(path[:len(path)-2], root, ev)
+	return followTagged(track, &path[len(path)-2], ev)
+}
+
+// patchTarget returns the present content at ptr on root.
+func patchTarget(root reflect.Value, ptr string, ev *evaluation) (reflect.Value, error) {
+	if ptr == "" {
+		return root, nil
+	}
+	path, err := patchPath(ptr)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	track := followTagged(patchParents(root, path, ev), &path[len(path)-1], ev)
+	if len(track) != 1 || !track[0].IsValid() {
+		return reflect.Value{}, fmt.Errorf("JSON Pointer %q has no value", ptr)
+	}
+	return track[0], nil
+}
+
+// patchGet returns the JSON of the content at ptr on root.
+func patchGet(root reflect.Value, ptr string) (json.RawMessage, error) {
+	v, err := patchTarget(root, ptr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !v.CanInterface() {
+		return nil, fmt.Errorf("JSON Pointer %q has unexported content", ptr)
+	}
+	return json.Marshal(v.Interface())
+}
+
+// patchAdd applies the add operation with value at ptr on root.
+func patchAdd(root reflect.Value, ptr string, value json.RawMessage) error {
+	if ptr == "" {
+		return decodeValue(root, value, ptr)
+	}
+	path, err := patchPath(ptr)
+	if err != nil {
+		return err
+	}
+	last := &path[len(path)-1]
+
+	ev := &evaluation{build: true}
+	defer ev.finish()
+	parents := patchParents(root, path, ev)
+	if len(parents) != 1 {
+		return fmt.Errorf("JSON Pointer %q has no parent", ptr)
+	}
+
+	if follow(parents[0], true).Kind() == reflect.Slice {
+		return withSlice(parents[0], func(s reflect.Value) error {
+			return insertJSON(s, last.selection, value, ptr)
+		})
+	}
+
+	targets := followTagged(parents, last, ev)
+	if len(targets) != 1 {
+		return fmt.Errorf("JSON Pointer %q does not apply to type %s", ptr, parents[0].Type())
+	}
+	return decodeValue(targets[0], value, ptr)
+}
+
+// withSlice applies f on the slice held by v. Slices in an interface are
+// copied for f, and the interface gets the result.
+func withSlice(v reflect.Value, f func(s reflect.Value) error) error {
+	s := follow(v, false)
+	if s.CanSet() || v.Kind() != reflect.Interface || !v.CanSet() {
+		return f(s)
+	}
+
+	c := reflect.New(s.Type()).Elem()
+	c.Set(s)
+	if err := f(c); err != nil {
+		return err
+	}
+	v.Set(c)
+	return nil
+}
+
+// insertJSON puts value in slice v at the element number in name, or at the
+// end for "-".
+func insertJSON(v reflect.Value, name string, value json.RawMessage, ptr string) error {
+	index, ok := wireIndex(name)
+	if name == "-" {
+		index, ok = v.Len(), true
+	}
+	if !ok || index > v.Len() {
+		return fmt.Errorf("JSON Pointer %q has no index in range of %d elements", ptr, v.Len())
+	}
+	if !v.CanSet() {
+		return fmt.Errorf("JSON Pointer %q has a slice which can not be set", ptr)
+	}
+
+	e := reflect.New(v.Type().Elem()).Elem()
+	if err := decodeValue(e, value, ptr); err != nil {
+		return err
+	}
+	v.Set(reflect.Append(v, e))
+	reflect.Copy(v.Slice(index+1, v.Len()), v.Slice(index, v.Len()-1))
+	v.Index(index).Set(e)
+	return nil
+}
+
+// patchRemove applies the remove operation at ptr on root.
+func patchRemove(root reflect.Value, ptr string) error {
+	if ptr == "" {
+		root.Set(reflect.Zero(root.Type()))
+		return nil
+	}
+	path, err := patchPath(ptr)
+	if err != nil {
+		return err
+	}
+	last := &path[len(path)-1]
+
+	ev := new(evaluation)
+	parents := patchParents(root, path, ev)
+	if track := followTagged(parents, last, nil); len(track) != 1 || !track[0].IsValid() {
+		return fmt.Errorf("JSON Pointer %q has no value", ptr)
+	}
+
+	if follow(parents[0], false).Kind() == reflect.Slice {
+		err = withSlice(parents[0], func(s reflect.Value) error {
+			if deleteSelection(s, last, nil) == 0 {
+				return fmt.Errorf("JSON Pointer %q has a slice which can not be set", ptr)
+			}
+			return nil
+		})
+	} else {
+		deleteSelection(parents[0], last, nil)
+	}
+	ev.finish()
+	return err
+}
+
+// patchReplace applies the replace operation with value at ptr on root.
+func patchReplace(root reflect.Value, ptr string, value json.RawMessage) error {
+	ev := new(evaluation)
+	v, err := patchTarget(root, ptr, ev)
+	if err != nil {
+		return err
+	}
+	if err := decodeValue(v, value, ptr); err != nil {
+		return err
+	}
+	ev.finish()
+	return nil
+}
+
+// patchMove applies the move operation from JSON Pointer from to ptr on root.
+func patchMove(root reflect.Value, from, ptr string) error {
+	if from == ptr {
+		_, err := patchTarget(root, from, nil)
+		return err
+	}
+	if strings.HasPrefix(ptr, from+"/") {
+		return fmt.Errorf("JSON Pointer %q can not move into its own child %q", from, ptr)
+	}
+
+	raw, err := patchGet(root, from)
+	if err != nil {
+		return err
+	}
+	if err := patchRemove(root, from); err != nil {
+		return err
+	}
+	return patchAdd(root, ptr, raw)
+}
+
+// patchTest applies the test operation with value at ptr on root.
+func patchTest(root reflect.Value, ptr string, value json.RawMessage) error {
+	raw, err := patchGet(root, ptr)
+	if err != nil {
+		return err
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(value, &want); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("JSON Pointer %q has %s, not %s", ptr, raw, value)
+	}
+	return nil
+}
+
+// atomic applies f on a copy of the value root points to. The content of the
+// copy goes back onto the original only when f succeeds. Pointers, maps and
+// slices in the original remain in place, with their content updated, such
+// that references into the original stay current.
+func atomic(root interface{}, f func(reflect.Value) error) error {
+	p := reflect.ValueOf(root)
+	if p.Kind() != reflect.Ptr || p.IsNil() {
+		return fmt.Errorf("goe el: patch root %T is not a non-nil pointer", root)
+	}
+
+	c := reflect.New(p.Type().Elem())
+	seen := map[visit]reflect.Value{{p: p.Pointer(), typ: p.Type()}: c}
+	origins := map[visit]reflect.Value{{p: c.Pointer(), typ: c.Type()}: p}
+	deepCopy(c.Elem(), p.Elem(), seen, origins)
+
+	if err := f(c.Elem()); err != nil {
+		return err
+	}
+	r := restorer{origins: origins, done: make(map[visit]bool)}
+	v := reflect.New(p.Type()).Elem()
+	v.Set(c)
+	r.restore(v)
+	return nil
+}
+
+// decodeValue sets v to the JSON value in raw, with ptr for the location.
+func decodeValue(v reflect.Value, raw json.RawMessage, ptr string) error {
+	if !v.CanSet() {
+		return fmt.Errorf("JSON Pointer %q has content which can not be set", ptr)
+	}
+	p := reflect.New(v.Type())
+	if err := json.Unmarshal(raw, p.Interface()); err != nil {
+		return fmt.Errorf("JSON Pointer %q: %w", ptr, err)
+	}
+	v.Set(p.Elem())
+	return nil
+}
+
+// setValue sets v to x, with ptr for the location.
+func setValue(v, x reflect.Value, ptr string) error {
+	switch {
+	case !v.CanSet():
+		return fmt.Errorf("JSON Pointer %q has content which can not be set", ptr)
+	case !x.Type().AssignableTo(v.Type()):
+		return fmt.Errorf("JSON Pointer %q: %s does not apply to type %s", ptr, x.Type(), v.Type())
+	}
+	v.Set(x)
+	return nil
+}
+
+// isJSONObject returns whether raw is a JSON object.
+func isJSONObject(raw json.RawMessage) bool {
+	raw = bytes.TrimLeft(raw, " \t\r\n")
+	return len(raw) != 0 && raw[0] == '{'
+}
+
+// escapeJSONPointer returns the reference token encoding of name.
+func escapeJSONPointer(name string) string {
+	name = strings.ReplaceAll(name, "~", "~0")
+	return strings.ReplaceAll(name, "/", "~1")
+}
+
+// wireSegment returns the "@json:" selection of name.
+func wireSegment(name string) (*segment, error) {
+	path, err := parsePath("/@json:" + quoteTagName(name))
+	if err != nil {
+		return nil, unprefixed(err)
+	}
+	return &path[0], nil
+}
+
+// unprefixed returns err without the package prefix, for wrapping by the
+// patch functions.
+func unprefixed(err error) error {
+	return errors.New(strings.TrimPrefix(err.Error(), "goe el: "))
+}
@@ -0,0 +1,303 @@
+package el
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+type patchCache struct {
+	TTL  int `json:"ttl"`
+	Hits uint
+}
+
+type patchNode struct {
+	Name   string            `json:"name"`
+	Cache  *patchCache       `json:"cache,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Ports  []int             `json:"ports,omitempty"`
+	Extra  interface{}       `json:"extra,omitempty"`
+	Peers  map[int]patchNode `json:"peers,omitempty"`
+	note   string
+}
+
+// RFC 7386, appendix A
+func TestMergePatchGeneric(t *testing.T) {
+	tests := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, test := range tests {
+		var x interface{}
+		if err := json.Unmarshal([]byte(test.target), &x); err != nil {
+			t.Fatal(err)
+		}
+		if err := MergePatch(&x, []byte(test.patch)); err != nil {
+			t.Errorf("%s on %s: %s", test.patch, test.target, err)
+			continue
+		}
+		got, err := json.Marshal(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%s on %s: got %s, want %s", test.patch, test.target, got, test.want)
+		}
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	x := &patchNode{
+		Name:   "a",
+		Labels: map[string]string{"env": "test", "tier": "db"},
+		Ports:  []int{80, 443},
+		Peers:  map[int]patchNode{7: {Name: "b"}},
+		note:   "kept",
+	}
+	patch := `{
+		"cache": {"ttl": 60, "Hits": 2},
+		"labels": {"env": "prod", "tier": null, "zone": "eu", "Content Type": "x"},
+		"ports": [8080],
+		"extra": {"x": null, "y": [true]},
+		"peers": {"7": {"name": "c"}, "8": {"ports": [1]}}
+	}`
+	if err := MergePatch(x, []byte(patch)); err != nil {
+		t.Fatal(err)
+	}
+	want := &patchNode{
+		Name:   "a",
+		Cache:  &patchCache{TTL: 60, Hits: 2},
+		Labels: map[string]string{"env": "prod", "zone": "eu", "Content Type": "x"},
+		Ports:  []int{8080},
+		Extra:  map[string]interface{}{"y": []interface{}{true}},
+		Peers:  map[int]patchNode{7: {Name: "c"}, 8: {Ports: []int{1}}},
+		note:   "kept",
+	}
+	verify.Values(t, "patched", x, want)
+
+	if err := MergePatch(x, []byte(`{"cache": null, "ports": null, "name": null}`)); err != nil {
+		t.Fatal(err)
+	}
+	want.Cache, want.Ports, want.Name = nil, nil, ""
+	verify.Values(t, "deleted", x, want)
+}
+
+func TestMergePatchAtomic(t *testing.T) {
+	x := &patchNode{
+		Name:   "a",
+		Cache:  &patchCache{TTL: 1},
+		Labels: map[string]string{"env": "test"},
+		Ports:  []int{80},
+	}
+	cache, labels := x.Cache, x.Labels
+
+	tests := []struct{ patch, err string }{
+		{`{"cache": {"ttl": 2}, "labels": {"env": 1}}`, `goe el: merge patch: JSON Pointer "/labels/env": json: cannot unmarshal number into Go value of type string`},
+		{`{"cache": {"ttl": 2}, "name": {"x": 1}}`, `goe el: merge patch: object at "/name" does not apply to type string`},
+		{`{"labels": {"env": null}, "nope": 1}`, `goe el: merge patch: JSON Pointer "/nope" does not apply to type el.patchNode`},
+		{`{"ports": [1], "peers": {"x": {}}}`, `goe el: merge patch: JSON Pointer "/peers/x" does not apply to type map[int]el.patchNode`},
+		{`{"name": "b"`, `goe el: merge patch is not valid JSON`},
+	}
+	for _, test := range tests {
+		err := MergePatch(x, []byte(test.patch))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: got error %v, want %s", test.patch, err, test.err)
+		}
+	}
+
+	want := &patchNode{
+		Name:   "a",
+		Cache:  &patchCache{TTL: 1},
+		Labels: map[string]string{"env": "test"},
+		Ports:  []int{80},
+	}
+	verify.Values(t, "unchanged", x, want)
+	verify.Values(t, "cache", cache, want.Cache)
+	verify.Values(t, "labels", labels, want.Labels)
+
+	if err := MergePatch(*x, []byte(`{}`)); err == nil {
+		t.Error("no error for non-pointer root")
+	}
+}
+
+// RFC 6902, appendix A
+func TestApplyPatchGeneric(t *testing.T) {
+	tests := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"child":{"grandchild":{}},"foo":"bar"}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{`{"foo":"bar"}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"baz":"bar","foo":"bar"}`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+	for _, test := range tests {
+		var x interface{}
+		if err := json.Unmarshal([]byte(test.doc), &x); err != nil {
+			t.Fatal(err)
+		}
+		if err := ApplyPatch(&x, []byte(test.patch)); err != nil {
+			t.Errorf("%s on %s: %s", test.patch, test.doc, err)
+			continue
+		}
+		got, err := json.Marshal(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%s on %s: got %s, want %s", test.patch, test.doc, got, test.want)
+		}
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	x := &patchNode{
+		Name:   "a",
+		Labels: map[string]string{"env": "test"},
+		Ports:  []int{80, 443},
+		Peers:  map[int]patchNode{7: {Name: "b"}},
+	}
+	patch := `[
+		{"op": "test", "path": "/name", "value": "a"},
+		{"op": "add", "path": "/cache/ttl", "value": 60},
+		{"op": "add", "path": "/ports/0", "value": 22},
+		{"op": "add", "path": "/ports/-", "value": 8080},
+		{"op": "remove", "path": "/ports/2"},
+		{"op": "replace", "path": "/labels/env", "value": "prod"},
+		{"op": "add", "path": "/labels/a b", "value": "c"},
+		{"op": "copy", "from": "/labels/env", "path": "/peers/7/name"},
+		{"op": "move", "from": "/peers/7", "path": "/peers/9"},
+		{"op": "add", "path": "/peers/9/labels/zone", "value": "eu"},
+		{"op": "remove", "path": "/cache/Hits"}
+	]`
+	if err := ApplyPatch(x, []byte(patch)); err != nil {
+		t.Fatal(err)
+	}
+	want := &patchNode{
+		Name:   "a",
+		Cache:  &patchCache{TTL: 60},
+		Labels: map[string]string{"env": "prod", "a b": "c"},
+		Ports:  []int{22, 80, 8080},
+		Peers:  map[int]patchNode{9: {Name: "prod", Labels: map[string]string{"zone": "eu"}}},
+	}
+	verify.Values(t, "patched", x, want)
+}
+
+func TestApplyPatchAtomic(t *testing.T) {
+	x := &patchNode{
+		Name:   "a",
+		Labels: map[string]string{"env": "test"},
+		Ports:  []int{80, 443},
+	}
+	labels, ports := x.Labels, x.Ports
+
+	tests := []struct{ patch, err string }{
+		{`[{"op": "add", "path": "/ports/-", "value": 1}, {"op": "test", "path": "/name", "value": "b"}]`,
+			`goe el: JSON Patch operation 1: JSON Pointer "/name" has "a", not "b"`},
+		{`[{"op": "remove", "path": "/ports/0"}, {"op": "remove", "path": "/labels/zone"}]`,
+			`goe el: JSON Patch operation 1: JSON Pointer "/labels/zone" has no value`},
+		{`[{"op": "replace", "path": "/labels/env", "value": "x"}, {"op": "replace", "path": "/cache/ttl", "value": 1}]`,
+			`goe el: JSON Patch operation 1: JSON Pointer "/cache/ttl" has no value`},
+		{`[{"op": "add", "path": "/ports/3", "value": 1}]`,
+			`goe el: JSON Patch operation 0: JSON Pointer "/ports/3" has no index in range of 2 elements`},
+		{`[{"op": "add", "path": "/ports/01", "value": 1}]`,
+			`goe el: JSON Patch operation 0: JSON Pointer "/ports/01" has no index in range of 2 elements`},
+		{`[{"op": "add", "path": "/name", "value": 1}]`,
+			`goe el: JSON Patch operation 0: JSON Pointer "/name": json: cannot unmarshal number into Go value of type string`},
+		{`[{"op": "add", "path": "/absent", "value": 1}]`,
+			`goe el: JSON Patch operation 0: JSON Pointer "/absent" does not apply to type el.patchNode`},
+		{`[{"op": "move", "from": "/labels", "path": "/labels/x"}]`,
+			`goe el: JSON Patch operation 0: JSON Pointer "/labels" can not move into its own child "/labels/x"`},
+		{`[{"op": "add", "path": "name", "value": "b"}]`,
+			`goe el: JSON Patch operation 0: JSON Pointer "name" does not start with a slash`},
+		{`[{"op": "copy", "path": "/name"}]`, `goe el: JSON Patch operation 0 has no from`},
+		{`[{"op": "add", "path": "/name"}]`, `goe el: JSON Patch operation 0 has no value`},
+		{`[{"op": "remove"}]`, `goe el: JSON Patch operation 0 has no path`},
+		{`[{"op": "delete", "path": "/name"}]`, `goe el: JSON Patch operation 0: unknown operation "delete"`},
+	}
+	for _, test := range tests {
+		err := ApplyPatch(x, []byte(test.patch))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: got error %v, want %s", test.patch, err, test.err)
+		}
+	}
+
+	if err := ApplyPatch(x, []byte(`{"op": "remove"}`)); err == nil || !strings.HasPrefix(err.Error(), "goe el: JSON Patch: ") {
+		t.Errorf("got error %v for patch object", err)
+	}
+
+	want := &patchNode{
+		Name:   "a",
+		Labels: map[string]string{"env": "test"},
+		Ports:  []int{80, 443},
+	}
+	verify.Values(t, "unchanged", x, want)
+	verify.Values(t, "labels", labels, want.Labels)
+	verify.Values(t, "ports", ports, want.Ports)
+}
+
+func TestPatchReferences(t *testing.T) {
+	x := &patchNode{
+		Name:   "a",
+		Cache:  &patchCache{TTL: 1},
+		Labels: map[string]string{"env": "test"},
+		Ports:  []int{80, 443},
+	}
+	cache, labels, ports := x.Cache, x.Labels, x.Ports
+
+	if err := MergePatch(x, []byte(`{"cache": {"ttl": 2}, "labels": {"zone": "eu"}}`)); err != nil {
+		t.Fatal("merge patch error:", err)
+	}
+	if err := ApplyPatch(x, []byte(`[{"op": "replace", "path": "/ports/0", "value": 8080}, {"op": "remove", "path": "/labels/env"}]`)); err != nil {
+		t.Fatal("JSON Patch error:", err)
+	}
+
+	if x.Cache != cache {
+		t.Error("cache pointer replaced")
+	}
+	verify.Values(t, "cache", cache, &patchCache{TTL: 2})
+	verify.Values(t, "labels", labels, map[string]string{"zone": "eu"})
+	verify.Values(t, "ports", ports, []int{8080, 443})
+}
+
+func TestDeepCopyCycle(t *testing.T) {
+	type node struct {
+		Next *node
+		Kids []interface{}
+	}
+	a := &node{}
+	a.Next = a
+	a.Kids = []interface{}{a, map[string]interface{}{"self": a}}
+
+	if err := MergePatch(a, []byte(`{"Kids": null}`)); err != nil {
+		t.Fatal(err)
+	}
+	if a.Kids != nil {
+		t.Errorf("got kids %v", a.Kids)
+	}
+	if a.Next != a {
+		t.Error("cycle lost")
+	}
+}
@@ -2,47 +2,262 @@ package el
 
 import (
 	"fmt"
-	"path"
+	"go/token"
 	"reflect"
 	"strconv"
-	"strings"
+	"sync"
 )
 
-// resolve follows expr on root.
-func resolve(expr string, root interface{}, buildCallbacks *[]finisher) (track []reflect.Value) {
-	track = []reflect.Value{follow(reflect.ValueOf(root), buildCallbacks != nil)}
+// segment is a compiled path-component.
+type segment struct {
+	// selection is the field name, with "*" for any and "" for none.
+	selection string
+	// key is the key selection, with "" for none.
+	key string
+	// index is the key as an element number, with -1 for none.
+	index int
+	// elements is the key as an element range, if any.
+	elements *elementRange
+	// filter is the key selection predicate, if any.
+	filter operand
+	// descent selects all content recursively.
+	descent bool
+	// last marks a recursive descent at the end of the path.
+	last bool
+	// call marks the selection as a method invocation.
+	call bool
+	// args has the literal arguments of the invocation, in Go notation.
+	args []string
+
+	// tag is the struct tag key for the selection, with "" for none.
+	tag string
+	// tagAny is the wildcard for tag selections.
+	tagAny bool
+
+	// fields has the struct field indices per reflect.Type, if any.
+	fields *sync.Map
+	// keys has the map key literal per reflect.Type, if any.
+	keys *sync.Map
+}
 
-	segments := strings.Split(path.Clean(expr), "/")[1:]
-	if segments[0] == "" { // root selection
-		return track
+// parsePath returns the path components of expr. Normalization is applied
+// conform path.Clean, with the exception that slashes inbetween square
+// brackets do not separate.
+func parsePath(expr string) ([]segment, error) {
+	if !isPath(expr) {
+		return nil, fmt.Errorf("goe el: expression %q is not a path", expr)
 	}
 
-	for _, selection := range segments {
-		if len(track) == 0 {
-			return nil
+	var path []segment
+	for i := 0; i < len(expr); {
+		i++ // slash
+		offset, keyOffset, callOffset, callEnd := i, -1, -1, -1
+		for i < len(expr) && expr[i] != '/' {
+			switch expr[i] {
+			case '[':
+				break // key follows
+			case '(':
+				if callOffset >= 0 || keyOffset >= 0 || i == offset {
+					return nil, fmt.Errorf("goe el: expression %q has malformed invocation at offset %d", expr, i)
+				}
+				end, err := parenEnd(expr, i)
+				if err != nil {
+					return nil, err
+				}
+				callOffset, callEnd, i = i, end, end
+				if i < len(expr) && expr[i] != '/' && expr[i] != '[' {
+					return nil, fmt.Errorf("goe el: expression %q has content after invocation at offset %d", expr, i)
+				}
+				continue
+			case '"', '`':
+				end, err := literalEnd(expr, i)
+				if err != nil {
+					return nil, err
+				}
+				i = end
+				continue
+			default:
+				i++
+				continue
+			}
+
+			end, err := bracketEnd(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			keyOffset, i = i, end
+			if i < len(expr) && expr[i] != '/' {
+				return nil, fmt.Errorf("goe el: expression %q has content after key at offset %d", expr, i)
+			}
 		}
 
-		var key string
-		if last := len(selection) - 1; selection[last] == ']' {
-			if i := strings.IndexByte(selection, '['); i >= 0 {
-				key = selection[i+1 : last]
-				if key != "" {
-					selection = selection[:i]
+		switch s := expr[offset:i]; s {
+		case "", ".":
+			continue
+		case "..":
+			if len(path) != 0 {
+				path = path[:len(path)-1]
+			}
+		case "**":
+			if len(path) == 0 || !path[len(path)-1].descent {
+				path = append(path, segment{selection: s, index: -1, descent: true})
+			}
+		default:
+			seg := segment{selection: s, index: -1}
+			if keyOffset >= 0 {
+				seg.selection, seg.key = expr[offset:keyOffset], expr[keyOffset+1:i-1]
+				switch {
+				case seg.selection == "":
+					return nil, fmt.Errorf("goe el: expression %q has key without selection at offset %d", expr, offset)
+				case seg.key == "":
+					return nil, fmt.Errorf("goe el: expression %q has empty key at offset %d", expr, keyOffset)
+				case seg.selection == "**":
+					return nil, fmt.Errorf("goe el: expression %q has key on recursive descent at offset %d", expr, keyOffset)
+				}
+				if seg.selection == "." {
+					seg.selection = ""
+				}
+				if seg.key[0] == '?' {
+					f, err := parseFilter(seg.key[1:])
+					if err != nil {
+						return nil, fmt.Errorf("goe el: expression %q: %w", expr, err)
+					}
+					seg.filter = f
+				}
+				if k, err := strconv.ParseUint(seg.key, 0, 64); err == nil && k < (1<<31) {
+					seg.index = int(k)
+				} else if r, ok, err := parseRange(seg.key); err != nil {
+					return nil, fmt.Errorf("goe el: expression %q: %w", expr, err)
+				} else if ok {
+					seg.elements = r
+				}
+			}
+			if callOffset >= 0 {
+				seg.selection, seg.call = expr[offset:callOffset], true
+				if !token.IsIdentifier(seg.selection) || !token.IsExported(seg.selection) {
+					return nil, fmt.Errorf("goe el: expression %q has no exported method name at offset %d", expr, offset)
+				}
+				args, err := splitArgs(expr[callOffset+1 : callEnd-1])
+				if err != nil {
+					return nil, fmt.Errorf("goe el: expression %q: %w", expr, err)
+				}
+				seg.args = args
+			} else if seg.selection != "" && seg.selection[0] == '@' {
+				if err := seg.parseTagSelection(); err != nil {
+					return nil, fmt.Errorf("goe el: expression %q: %w", expr, err)
 				}
 			}
+			path = append(path, seg)
+		}
+	}
+	if n := len(path); n != 0 && path[n-1].descent {
+		path[n-1].last = true
+	}
+	return path, nil
+}
+
+// bracketEnd returns the offset after the square bracket which closes the one
+// at offset. Quoted literals are skipped.
+func bracketEnd(expr string, offset int) (int, error) {
+	depth := 0
+	for i := offset; i < len(expr); i++ {
+		switch c := expr[i]; c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		case '"', '\'', '`':
+			end, err := literalEnd(expr, i)
+			if err != nil {
+				return 0, err
+			}
+			i = end - 1
 		}
+	}
+	return 0, fmt.Errorf("goe el: expression %q has unterminated key at offset %d", expr, offset)
+}
 
-		if selection != "." {
-			track = followField(track, selection, buildCallbacks != nil)
+// parenEnd returns the offset after the parenthesis which closes the one at
+// offset. Quoted literals are skipped.
+func parenEnd(expr string, offset int) (int, error) {
+	for i := offset + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case ')':
+			return i + 1, nil
+		case '"', '\'', '`':
+			end, err := literalEnd(expr, i)
+			if err != nil {
+				return 0, err
+			}
+			i = end - 1
 		}
-		if key != "" {
-			track = followKey(track, key, buildCallbacks)
+	}
+	return 0, fmt.Errorf("goe el: expression %q has unterminated invocation at offset %d", expr, offset)
+}
+
+// literalEnd returns the offset after the quoted literal at offset.
+func literalEnd(expr string, offset int) (int, error) {
+	quote := expr[offset]
+	for i := offset + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case quote:
+			return i + 1, nil
+		case '\\':
+			if quote != '`' {
+				i++
+			}
 		}
 	}
+	return 0, fmt.Errorf("goe el: expression %q has unterminated literal at offset %d", expr, offset)
+}
 
-	if buildCallbacks == nil {
+// resolve follows path on root.
+func resolve(path []segment, root interface{}, ev *evaluation) []reflect.Value {
+	return resolveValue(path, reflect.ValueOf(root), ev)
+}
+
+// resolveTargets is like resolveValue, without following pointers and
+// interfaces at the end, such that the content can be set as is.
+func resolveTargets(path []segment, root reflect.Value, ev *evaluation) []reflect.Value {
+	if len(path) == 0 {
+		return []reflect.Value{root}
+	}
+	track := resolveValue(path[:len(path)-1], root, ev)
+	if len(track) == 0 {
+		return nil
+	}
+
+	seg := &path[len(path)-1]
+	track = ev.selects(track, seg)
+	if !ev.spend(len(track)) {
+		return nil
+	}
+	return ev.leaves(track, seg)
+}
+
+// resolveValue follows path on root.
+func resolveValue(path []segment, root reflect.Value, ev *evaluation) (track []reflect.Value) {
+	track = []reflect.Value{follow(root, ev.builds())}
+
+	for i := range path {
+		if len(track) == 0 {
+			return nil
+		}
+
+		seg := &path[i]
+		track = ev.selects(track, seg)
+		if !ev.spend(len(track)) {
+			return nil
+		}
+		track = ev.leaves(track, seg)
+	}
+
+	if !ev.builds() {
 		for i, v := range track {
-			track[i] = follow(v, buildCallbacks != nil)
+			track[i] = follow(v, ev.builds())
 		}
 	} else {
 		writeIndex := 0
@@ -67,26 +282,82 @@ func resolve(expr string, root interface{}, buildCallbacks *[]finisher) (track [
 	return track
 }
 
-// followField returns all fields matching s from track.
-func followField(track []reflect.Value, s string, doBuild bool) []reflect.Value {
-	if s == "*" {
-		// Count fields with n and filter struct types in track while we're at it.
-		writeIndex, n := 0, 0
-		for _, v := range track {
+// selects returns the selection of seg on track. When tracing, the hops of ev
+// have the location of each value returned, in the same order.
+func (ev *evaluation) selects(track []reflect.Value, seg *segment) []reflect.Value {
+	tracing := ev.tracing()
+	if tracing {
+		ev.hops, ev.reasons = nil, nil
+	}
+	if seg.descent {
+		return followDescent(track, ev)
+	}
+
+	if seg.selection != "" || seg.tag != "" {
+		track = followField(track, seg, ev)
+	} else if tracing {
+		for i := range track {
+			ev.hop(i, "")
+		}
+	}
+	if seg.key != "" {
+		var fields []hop
+		if tracing {
+			fields, ev.hops = ev.hops, nil
+		}
+		track = followKey(track, seg, ev)
+		if tracing {
+			for i := range ev.hops {
+				h := &ev.hops[i]
+				f := fields[h.from]
+				h.from, h.rel = f.from, joinLocation(f.rel, h.rel)
+			}
+		}
+	}
+	return track
+}
+
+// followField returns all fields matching seg from track.
+func followField(track []reflect.Value, seg *segment, ev *evaluation) []reflect.Value {
+	if seg.call {
+		if ev.modifies() {
+			return nil // results are not modifiable
+		}
+		return followMethod(track, seg, ev)
+	}
+	if seg.tag != "" {
+		return followTagged(track, seg, ev)
+	}
+
+	doBuild := ev.builds()
+	if seg.selection == "*" {
+		// Count fields with n and mark the other types in track invalid.
+		n := 0
+		for i, v := range track {
 			v := follow(v, doBuild)
 			if v.Kind() == reflect.Struct {
 				n += v.Type().NumField()
-				track[writeIndex] = v
-				writeIndex++
 			}
+			track[i] = v
+		}
+		if !ev.fits(n) {
+			return nil
 		}
-		track = track[:writeIndex]
 
-		dst := make([]reflect.Value, n)
-		for _, v := range track {
-			for i := v.Type().NumField() - 1; i >= 0; i-- {
-				n--
-				dst[n] = v.Field(i)
+		tracing := ev.tracing()
+		dst := make([]reflect.Value, 0, n)
+		for from, v := range track {
+			if v.Kind() != reflect.Struct {
+				continue
+			}
+			t := v.Type()
+			for i, n := 0, v.NumField(); i < n; i++ {
+				if ev.permitsField(t, []int{i}) {
+					dst = append(dst, v.Field(i))
+					if tracing {
+						ev.hop(from, "/"+t.Field(i).Name)
+					}
+				}
 			}
 		}
 		return dst
@@ -94,45 +365,228 @@ func followField(track []reflect.Value, s string, doBuild bool) []reflect.Value
 
 	// Write result back to track with writeIndex to safe memory.
 	writeIndex := 0
-	for _, v := range track {
-		v := follow(v, doBuild)
-		if v.Kind() == reflect.Struct {
-			track[writeIndex] = v.FieldByName(s)
+	for from, v := range track {
+		v := follow(v, ev.builds())
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		index := seg.fieldIndex(v.Type())
+		if index == nil {
+			continue
+		}
+		if !ev.permitsField(v.Type(), index) {
+			ev.deny()
+			return nil
+		}
+		if f := fieldByIndex(v, index, ev.builds()); f.IsValid() {
+			track[writeIndex] = f
 			writeIndex++
+			if ev.tracing() {
+				ev.hop(from, fieldLocation(v.Type(), index))
+			}
 		}
 	}
 	return track[:writeIndex]
 }
 
-// followKey returns all elements matching s from track.
-func followKey(track []reflect.Value, s string, buildCallbacks *[]finisher) []reflect.Value {
-	if s == "*" {
-		// Count elements with n and filter keyed types in track while we're at it.
-		writeIndex, n := 0, 0
-		for _, v := range track {
-			v := follow(v, buildCallbacks != nil)
+// visit identifies content for cycle detection.
+type visit struct {
+	p   uintptr
+	n   int // length for slices
+	typ reflect.Type
+}
+
+// followDescent returns all of track, including its content, recursively.
+// Each pointer, map and slice is followed at most once, which ensures
+// termination on reference cycles.
+func followDescent(track []reflect.Value, ev *evaluation) []reflect.Value {
+	seen := make(map[visit]struct{})
+	var dst []reflect.Value
+	for from, v := range track {
+		dst = descend(dst, v, seen, ev, from, "")
+	}
+	return dst
+}
+
+// descend appends v and its content to dst. Nil pointers and nil interfaces
+// are omitted, and so are pointers seen before. When tracing, the hops go from
+// the track at index from, with v at location rel.
+func descend(dst []reflect.Value, v reflect.Value, seen map[visit]struct{}, ev *evaluation, from int, rel string) []reflect.Value {
+	if !ev.fits(len(dst) + 1) {
+		return dst
+	}
+	e := v
+	for e.Kind() == reflect.Ptr || e.Kind() == reflect.Interface {
+		if e.IsNil() {
+			return dst
+		}
+		if e.Kind() == reflect.Ptr {
+			key := visit{p: e.Pointer(), typ: e.Type()}
+			if _, ok := seen[key]; ok {
+				return dst
+			}
+			seen[key] = struct{}{}
+		}
+		e = e.Elem()
+	}
+	dst = append(dst, v)
+	ev.hop(from, rel)
+	v = e
+
+	tracing := ev.tracing()
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i, n := 0, v.NumField(); i < n; i++ {
+			if ev.permitsField(t, []int{i}) {
+				var at string
+				if tracing {
+					at = rel + "/" + t.Field(i).Name
+				}
+				dst = descend(dst, v.Field(i), seen, ev, from, at)
+			}
+		}
+
+	case reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		key := visit{p: v.Pointer(), n: v.Len(), typ: v.Type()}
+		if _, ok := seen[key]; ok {
+			break
+		}
+		seen[key] = struct{}{}
+		fallthrough
+	case reflect.Array:
+		for i, n := 0, v.Len(); i < n; i++ {
+			var at string
+			if tracing {
+				at = keyLocation(rel, strconv.Itoa(i))
+			}
+			dst = descend(dst, v.Index(i), seen, ev, from, at)
+		}
+
+	case reflect.Map:
+		if v.IsNil() {
+			break
+		}
+		key := visit{p: v.Pointer(), typ: v.Type()}
+		if _, ok := seen[key]; ok {
+			break
+		}
+		seen[key] = struct{}{}
+
+		keys := v.MapKeys()
+		if tracing {
+			sortKeys(keys)
+		}
+		values := make([]reflect.Value, 1)
+		for _, k := range keys {
+			n := 0
+			followMap(values, &n, v, k, ev)
+			if n == 0 {
+				continue
+			}
+			var at string
+			if tracing {
+				at = keyLocation(rel, keyLiteral(k))
+			}
+			dst = descend(dst, values[0], seen, ev, from, at)
+		}
+	}
+	return dst
+}
+
+// fieldIndex returns the index sequence of the field selection in t, with nil
+// for no match.
+func (seg *segment) fieldIndex(t reflect.Type) []int {
+	if seg.fields == nil {
+		return seg.lookupField(t)
+	}
+
+	if index, ok := seg.fields.Load(t); ok {
+		return index.([]int)
+	}
+	index := seg.lookupField(t)
+	seg.fields.Store(t, index)
+	return index
+}
+
+func (seg *segment) lookupField(t reflect.Type) []int {
+	if seg.tag != "" {
+		return taggedField(t, seg.tag, seg.selection)
+	}
+	f, _ := t.FieldByName(seg.selection)
+	return f.Index
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but without the panic on
+// nil pointers to embedded structs. Such fields have no result, unless they
+// can be constructed with doBuild.
+func fieldByIndex(v reflect.Value, index []int, doBuild bool) reflect.Value {
+	for i, x := range index {
+		if i != 0 {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					if !doBuild || !v.CanSet() {
+						return reflect.Value{}
+					}
+					v.Set(reflect.New(v.Type().Elem()))
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// followKey returns all elements matching seg from track.
+func followKey(track []reflect.Value, seg *segment, ev *evaluation) []reflect.Value {
+	if seg.filter != nil || seg.elements != nil {
+		return followElements(track, seg, ev)
+	}
+
+	tracing := ev.tracing()
+	if seg.key == "*" {
+		// Count elements with n and follow track while we're at it.
+		n := 0
+		for i, v := range track {
+			v := follow(v, ev.builds())
 			switch v.Kind() {
 			case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
 				n += v.Len()
-				track[writeIndex] = v
-				writeIndex++
 			}
+			track[i] = v
+		}
+		if !ev.fits(n) {
+			return nil
 		}
-		track = track[:writeIndex]
 
 		dst := make([]reflect.Value, n)
-		writeIndex = 0
-		for _, v := range track {
+		writeIndex := 0
+		for from, v := range track {
 			switch v.Kind() {
 			case reflect.Array, reflect.Slice, reflect.String:
 				for i, n := 0, v.Len(); i < n; i++ {
 					dst[writeIndex] = v.Index(i)
 					writeIndex++
+					if tracing {
+						ev.hop(from, "["+strconv.Itoa(i)+"]")
+					}
 				}
 
 			case reflect.Map:
-				for _, key := range v.MapKeys() {
-					followMap(dst, &writeIndex, v, key, buildCallbacks)
+				keys := v.MapKeys()
+				if tracing {
+					sortKeys(keys)
+				}
+				for _, key := range keys {
+					offset := writeIndex
+					followMap(dst, &writeIndex, v, key, ev)
+					if tracing && writeIndex != offset {
+						ev.hop(from, "["+keyLiteral(key)+"]")
+					}
 				}
 
 			}
@@ -142,26 +596,35 @@ func followKey(track []reflect.Value, s string, buildCallbacks *[]finisher) []re
 
 	// Write result back to track with writeIndex to safe memory.
 	writeIndex := 0
-	for _, v := range track {
-		v := follow(v, buildCallbacks != nil)
+	for from, v := range track {
+		v := follow(v, ev.builds())
 		switch v.Kind() {
 		case reflect.Array, reflect.Slice, reflect.String:
-			if k, err := strconv.ParseUint(s, 0, 64); err == nil && k < (1<<31) {
-				i := int(k)
+			if i := seg.index; i >= 0 {
 				if i >= v.Len() {
-					if v.Kind() != reflect.Slice || !v.CanSet() {
+					if v.Kind() != reflect.Slice || !v.CanSet() || ev.inspects() {
 						continue
 					}
 					n := i - v.Len() + 1
+					if !ev.grows(n) {
+						continue
+					}
 					v.Set(reflect.AppendSlice(v, reflect.MakeSlice(v.Type(), n, n)))
 				}
 				track[writeIndex] = v.Index(i)
 				writeIndex++
+				if tracing {
+					ev.hop(from, "["+strconv.Itoa(i)+"]")
+				}
 			}
 
 		case reflect.Map:
-			if key := parseLiteral(s, v.Type().Key()); key != nil {
-				followMap(track, &writeIndex, v, *key, buildCallbacks)
+			if key := seg.mapKey(v.Type().Key()); key != nil {
+				offset := writeIndex
+				followMap(track, &writeIndex, v, *key, ev)
+				if tracing && writeIndex != offset {
+					ev.hop(from, "["+keyLiteral(*key)+"]")
+				}
 			}
 
 		}
@@ -169,6 +632,125 @@ func followKey(track []reflect.Value, s string, buildCallbacks *[]finisher) []re
 	return track[:writeIndex]
 }
 
+// followElements returns all elements matching the filter or the range of seg
+// from track. Slices grow to the end of a range, like they do for indices, when
+// possible.
+func followElements(track []reflect.Value, seg *segment, ev *evaluation) []reflect.Value {
+	tracing := ev.tracing()
+	var dst []reflect.Value
+	for from, v := range track {
+		if !ev.fits(len(dst)) {
+			return nil
+		}
+		v := follow(v, ev.builds())
+		switch v.Kind() {
+		case reflect.Array, reflect.Slice, reflect.String:
+			if r := seg.elements; r != nil && r.hasEnd && r.step > 0 && r.end > v.Len() && v.Kind() == reflect.Slice && v.CanSet() && ev.builds() {
+				grow := r.end - v.Len()
+				if ev.grows(grow) {
+					v.Set(reflect.AppendSlice(v, reflect.MakeSlice(v.Type(), grow, grow)))
+				}
+			}
+			for _, i := range seg.elementIndices(v, ev) {
+				dst = append(dst, v.Index(i))
+				if tracing {
+					ev.hop(from, "["+strconv.Itoa(i)+"]")
+				}
+			}
+
+		case reflect.Map:
+			keys := seg.matchKeys(v, ev)
+			if tracing {
+				sortKeys(keys)
+			}
+			for _, key := range keys {
+				offset := len(dst)
+				dst = append(dst, reflect.Value{})
+				writeIndex := offset
+				followMap(dst, &writeIndex, v, key, ev)
+				dst = dst[:writeIndex]
+				if tracing && writeIndex != offset {
+					ev.hop(from, "["+keyLiteral(key)+"]")
+				}
+			}
+
+		}
+	}
+	return dst
+}
+
+// elementIndices returns the element numbers matching the key selection of seg
+// in v, which is either an array, a slice or a string. Filters evaluate with
+// the budget of ev, if any.
+func (seg *segment) elementIndices(v reflect.Value, ev *evaluation) []int {
+	n := v.Len()
+	switch {
+	case seg.filter != nil:
+		var a []int
+		for i := 0; i < n; i++ {
+			if truth(seg.filter.eval(v.Index(i), ev)) {
+				a = append(a, i)
+			}
+		}
+		return a
+
+	case seg.elements != nil:
+		return seg.elements.indices(n)
+
+	case seg.key == "*":
+		a := make([]int, n)
+		for i := range a {
+			a[i] = i
+		}
+		return a
+
+	case seg.index >= 0 && seg.index < n:
+		return []int{seg.index}
+	}
+	return nil
+}
+
+// matchKeys returns the keys matching the key selection of seg in map v.
+// Literals match regardless of their presence. Filters evaluate with the
+// budget of ev, if any.
+func (seg *segment) matchKeys(v reflect.Value, ev *evaluation) []reflect.Value {
+	switch {
+	case seg.filter != nil:
+		var a []reflect.Value
+		for _, key := range v.MapKeys() {
+			if truth(seg.filter.eval(v.MapIndex(key), ev)) {
+				a = append(a, key)
+			}
+		}
+		return a
+
+	case seg.key == "*":
+		return v.MapKeys()
+
+	case seg.elements != nil && !seg.elements.single:
+		return nil
+	}
+
+	if key := seg.mapKey(v.Type().Key()); key != nil {
+		return []reflect.Value{*key}
+	}
+	return nil
+}
+
+// mapKey returns the key selection for t, with nil for no match.
+func (seg *segment) mapKey(t reflect.Type) *reflect.Value {
+	if seg.keys == nil {
+		return parseLiteral(seg.key, t)
+	}
+
+	if key, ok := seg.keys.Load(t); ok {
+		return key.(*reflect.Value)
+	}
+	key := parseLiteral(seg.key, t)
+	seg.keys.Store(t, key)
+	return key
+}
+
 // follow tracks content.
 func follow(v reflect.Value, doBuild bool) (f reflect.Value) {
 	for {
@@ -211,10 +793,10 @@ func (w *mapWrap) Finish() {
 	w.m.SetMapIndex(*w.k, *w.v)
 }
 
-func followMap(dst []reflect.Value, dstIndex *int, m reflect.Value, key reflect.Value, buildCallbacks *[]finisher) {
+func followMap(dst []reflect.Value, dstIndex *int, m reflect.Value, key reflect.Value, ev *evaluation) {
 	v := m.MapIndex(key)
 
-	if buildCallbacks != nil {
+	if ev.modifies() {
 		if !m.CanInterface() {
 			return
 		}
@@ -225,10 +807,13 @@ func followMap(dst []reflect.Value, dstIndex *int, m reflect.Value, key reflect.
 			pv.Set(v)
 			v = pv
 		} else {
+			if !ev.build {
+				return
+			}
 			v = reflect.New(m.Type().Elem()).Elem()
 		}
 
-		*buildCallbacks = append(*buildCallbacks, &mapWrap{m: &m, k: &key, v: &v})
+		ev.callbacks = append(ev.callbacks, &mapWrap{m: &m, k: &key, v: &v})
 	}
 
 	dst[*dstIndex] = v
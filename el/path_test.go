@@ -0,0 +1,187 @@
+package el
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+type Service struct {
+	Name     string
+	Timeout  int
+	Backends []*Service
+	Options  map[string]interface{}
+	Parent   *Service
+}
+
+func TestRecursiveDescent(t *testing.T) {
+	root := &struct{ Services []*Service }{
+		Services: []*Service{
+			{Name: "api", Timeout: 1, Backends: []*Service{
+				{Name: "db", Timeout: 2},
+				{Name: "cache", Timeout: 3, Options: map[string]interface{}{
+					"fallback": &Service{Name: "disk", Timeout: 4},
+				}},
+			}},
+			{Name: "web", Timeout: 5},
+		},
+	}
+	// reference cycles
+	for _, s := range root.Services {
+		for _, b := range s.Backends {
+			b.Parent = s
+		}
+	}
+	root.Services[1].Parent = root.Services[1]
+
+	tests := []struct {
+		expr string
+		want []int64
+	}{
+		{"/**/Timeout", []int64{1, 2, 3, 4, 5}},
+		{"/Services/**/Timeout", []int64{1, 2, 3, 4, 5}},
+		// parent reference leads back up
+		{"/Services[0]/Backends/**/Timeout", []int64{1, 2, 3, 4}},
+		{"/Services[0]/Backends[*]/Options/**/Timeout", []int64{4}},
+		{"/Services[1]/**/Timeout", []int64{5}},
+		{"/**/**/Timeout", []int64{1, 2, 3, 4, 5}},
+		{`/**/Options["fallback"]/Timeout`, []int64{4}},
+		{`/**/.[?Name == "db"]/Timeout`, []int64{2}},
+		{"/**/Mis", nil},
+	}
+	for _, test := range tests {
+		got := Ints(test.expr, root)
+		if len(got) == 0 {
+			got = nil
+		}
+		verify.Values(t, test.expr, sortedInts(got), test.want)
+	}
+}
+
+func TestRecursiveDescentAssign(t *testing.T) {
+	root := &struct {
+		A struct{ Timeout int }
+		M map[string]struct{ Timeout int }
+		P *struct{ Timeout int }
+	}{
+		M: map[string]struct{ Timeout int }{"x": {1}, "y": {2}},
+	}
+
+	if n := Assign(root, "/**/Timeout", 99); n != 3 {
+		t.Errorf("got %d updates, want 3", n)
+	}
+	verify.Values(t, "timeouts", sortedInts(Ints("/**/Timeout", root)), []int64{99, 99, 99})
+	if root.P != nil {
+		t.Error("recursive descent constructed a nil pointer")
+	}
+}
+
+func TestRecursiveDescentCompileErrors(t *testing.T) {
+	if _, err := Compile("/**[0]"); err == nil {
+		t.Error("no error for key on recursive descent")
+	}
+}
+
+// sortedInts returns a in order (with insertion sort).
+func sortedInts(a []int64) []int64 {
+	for i := 1; i < len(a); i++ {
+		for j := i; j > 0 && a[j] < a[j-1]; j-- {
+			a[j], a[j-1] = a[j-1], a[j]
+		}
+	}
+	return a
+}
+
+func TestElementRanges(t *testing.T) {
+	root := struct {
+		S []int
+		A [4]int
+		T string
+		M map[int]int
+	}{
+		S: []int{0, 1, 2, 3, 4, 5},
+		A: [4]int{10, 11, 12, 13},
+		T: "abc",
+		M: map[int]int{-1: 42},
+	}
+
+	tests := []struct {
+		expr string
+		want []int64
+	}{
+		{"/S[-1]", []int64{5}},
+		{"/S[-6]", []int64{0}},
+		{"/S[-7]", nil},
+		{"/S[2:5]", []int64{2, 3, 4}},
+		{"/S[:3]", []int64{0, 1, 2}},
+		{"/S[4:]", []int64{4, 5}},
+		{"/S[::2]", []int64{0, 2, 4}},
+		{"/S[1::2]", []int64{1, 3, 5}},
+		{"/S[-2:]", []int64{4, 5}},
+		{"/S[:-4]", []int64{0, 1}},
+		{"/S[::-1]", []int64{5, 4, 3, 2, 1, 0}},
+		{"/S[4:1:-2]", []int64{4, 2}},
+		{"/S[3:99]", []int64{3, 4, 5}},
+		{"/S[5:2]", nil},
+		{"/A[-2:]", []int64{12, 13}},
+		{"/A[0x1:0x3]", []int64{11, 12}},
+		{"/M[-1]", []int64{42}},
+		{"/M[0:1]", nil},
+	}
+	for _, test := range tests {
+		got := Ints(test.expr, root)
+		if len(got) == 0 {
+			got = nil
+		}
+		verify.Values(t, test.expr, got, test.want)
+	}
+
+	verify.Values(t, "string range", Uints("/T[1:]", root), []uint64{'b', 'c'})
+	verify.Values(t, "string index", Uints("/T[-1]", root), []uint64{'c'})
+}
+
+func TestElementRangeAssigns(t *testing.T) {
+	x := &struct {
+		S []int
+		A [3]int
+	}{S: []int{1, 2, 3}}
+
+	if n := Assign(x, "/S[-1]", 9); n != 1 {
+		t.Errorf("got %d updates for negative index, want 1", n)
+	}
+	if n := Assign(x, "/S[::2]", 7); n != 2 {
+		t.Errorf("got %d updates for step, want 2", n)
+	}
+	verify.Values(t, "slice", x.S, []int{7, 2, 7})
+
+	if n := Assign(x, "/S[2:5]", 5); n != 3 {
+		t.Errorf("got %d updates for growth, want 3", n)
+	}
+	verify.Values(t, "grown slice", x.S, []int{7, 2, 5, 5, 5})
+
+	if n := Assign(x, "/S[-9]", 1); n != 0 {
+		t.Errorf("got %d updates for negative out of bounds, want 0", n)
+	}
+	if n := Assign(x, "/A[1:9]", 4); n != 2 {
+		t.Errorf("got %d updates on array, want 2", n)
+	}
+	verify.Values(t, "array", x.A, [3]int{0, 4, 4})
+}
+
+func TestElementRangeCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		"/S[::0]",
+		"/S[1:2:3:4]",
+		"/S[1-:2]",
+		"/S[:99999999999]",
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("compile %q: no error", expr)
+		}
+	}
+
+	// not a range
+	if _, err := Compile(`/M["a:b"]`); err != nil {
+		t.Error("quoted key with colon:", err)
+	}
+}
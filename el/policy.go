@@ -0,0 +1,397 @@
+package el
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Policy restricts the content available to expressions, e.g., for expressions
+// from untrusted sources. The zero value permits everything, with the exception
+// of method invocations, which need Limits.AllowCalls.
+//
+// Evaluation which selects a denied field by name, or by tag name, has no
+// result, as if the expression did not match. Wildcards and recursive descents
+// leave denied fields out instead. The path patterns apply to the expression
+// as a whole, with no result, or no modification, when they don't permit.
+//
+// The policy applies to the paths of expressions, not to the content as such.
+// A selection includes all content of the values selected, which may hold
+// denied fields. With ExportedOnly or Tag set, a recursive descent at the end
+// of a path selects leaves only, conform Flatten, and lookups return any other
+// content as a deep copy, with the denied fields, and the non-exported fields,
+// at their zero value. The same goes for Locate and ProjectMapExpr. Modification
+// applies to the content as is, including any denied fields in there. With any
+// of ExportedOnly, Tag, Read or ReadWrite set, method invocations are denied,
+// unless a pattern permits the same invocation, on top of Limits.AllowCalls.
+type Policy struct {
+	// ExportedOnly denies non-exported fields.
+	ExportedOnly bool
+	// Tag denies fields without a struct tag for the key, e.g., "json".
+	// Fields tagged with name "-" are denied too.
+	Tag string
+
+	// Read has path patterns available for reading, and ReadWrite has
+	// path patterns available for both reading and modification. Any
+	// path covered by a pattern is permitted, including all content at
+	// the path. Wildcards match any field or key, and a recursive descent
+	// matches anything from there on, except for method invocations. The
+	// invocations need a pattern with the same invocation at the position.
+	// Paths in filters need read permission.
+	// When both are nil, then all paths are permitted, with the exception
+	// of method invocations under ExportedOnly or Tag.
+	Read, ReadWrite []string
+
+	// Limits bounds the expressions and their evaluation.
+	Limits Limits
+}
+
+// Compile is like the package-level function, with p applied on each
+// evaluation of the result.
+func (p Policy) Compile(expr string) (*Expr, error) {
+	x, err := p.Limits.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if p.Read != nil || p.ReadWrite != nil {
+		readWrite, err := parsePatterns(p.ReadWrite)
+		if err != nil {
+			return nil, err
+		}
+		read, err := parsePatterns(p.Read)
+		if err != nil {
+			return nil, err
+		}
+		read = append(read, readWrite...)
+
+		if x.x != nil {
+			for _, path := range operandPaths(x.x) {
+				if !permits(path, read, read) {
+					x.noRead = true
+				}
+			}
+			x.noWrite = true // modification applies to paths only
+		} else {
+			x.noRead = !permits(x.path, read, read)
+			x.noWrite = !permits(x.path, readWrite, read)
+		}
+	} else if p.ExportedOnly || p.Tag != "" {
+		paths := []relPath{x.path}
+		if x.x != nil {
+			paths = operandPaths(x.x)
+		}
+		for _, path := range paths {
+			if hasCall(path) {
+				// no pattern to permit
+				x.noRead, x.noWrite = true, true
+			}
+		}
+	}
+	x.policy = &p
+	return x, nil
+}
+
+// parsePatterns returns the paths of each pattern.
+func parsePatterns(patterns []string) ([][]segment, error) {
+	paths := make([][]segment, len(patterns))
+	for i, s := range patterns {
+		path, err := parsePath(s)
+		if err != nil {
+			return nil, fmt.Errorf("goe el: policy pattern: %w", err)
+		}
+		paths[i] = path
+	}
+	return paths, nil
+}
+
+// permits returns whether any of the patterns covers path. Paths in filters
+// need coverage from read.
+func permits(path []segment, patterns, read [][]segment) bool {
+	for _, pattern := range patterns {
+		if covers(pattern, path, read) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers returns whether all content selected by path is within the content
+// selected by pattern. Paths in filters need coverage from read.
+func covers(pattern, path []segment, read [][]segment) bool {
+	for i := range path {
+		if i >= len(pattern) || pattern[i].descent {
+			return !hasCall(path[i:]) // content of the pattern
+		}
+		p, s := &pattern[i], &path[i]
+		last := i == len(pattern)-1 || pattern[i+1].descent
+		if s.descent || !p.coversSelection(s) || !p.coversKey(s, last) {
+			return false
+		}
+
+		if s.filter != nil {
+			// filter paths are relative to each element
+			e := *s
+			e.key, e.index, e.elements, e.filter = "*", -1, nil, nil
+			context := append(path[:i:i], e)
+			for _, rel := range operandPaths(s.filter) {
+				if !permits(append(context[:len(context):len(context)], rel...), read, read) {
+					return false
+				}
+			}
+		}
+		if p.key == "" && s.key != "" {
+			return !hasCall(path[i+1:]) // key in content of the pattern
+		}
+	}
+	return len(path) >= len(pattern)
+}
+
+// coversSelection returns whether the field selection of seg is within the one
+// of pattern segment p.
+func (p *segment) coversSelection(seg *segment) bool {
+	switch {
+	case p.call:
+		if !seg.call || seg.selection != p.selection || len(seg.args) != len(p.args) {
+			return false
+		}
+		for i := range p.args {
+			if seg.args[i] != p.args[i] {
+				return false
+			}
+		}
+		return true
+	case seg.call:
+		return false
+	case p.selection == "":
+		return seg.selection == ""
+	case p.tag == "" && p.selection == "*":
+		return true
+	case p.tag != "":
+		return seg.tag == p.tag && (p.tagAny || !seg.tagAny && seg.selection == p.selection)
+	}
+	return seg.tag == "" && seg.selection == p.selection
+}
+
+// coversKey returns whether the key selection of seg is within the one of
+// pattern segment p. Any key is content of a last segment without a key,
+// i.e., one at the end of the pattern or before a recursive descent.
+func (p *segment) coversKey(seg *segment, last bool) bool {
+	if p.key == "*" || p.key == "" && last {
+		return true
+	}
+	return seg.key == p.key
+}
+
+// hasCall returns whether path, or any path in its filters, has a method
+// invocation.
+func hasCall(path []segment) bool {
+	for i := range path {
+		if path[i].call {
+			return true
+		}
+		if f := path[i].filter; f != nil {
+			for _, rel := range operandPaths(f) {
+				if hasCall(rel) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// operandPaths returns the paths in x.
+func operandPaths(x operand) []relPath {
+	switch x := x.(type) {
+	case relPath:
+		return []relPath{x}
+	case *logical:
+		return append(operandPaths(x.x), operandPaths(x.y)...)
+	case *comparison:
+		return append(operandPaths(x.x), operandPaths(x.y)...)
+	case *arithmetic:
+		return append(operandPaths(x.x), operandPaths(x.y)...)
+	case *conditional:
+		return append(append(operandPaths(x.cond), operandPaths(x.x)...), operandPaths(x.y)...)
+	case *not:
+		return operandPaths(x.x)
+	case *negation:
+		return operandPaths(x.x)
+	case *call:
+		var paths []relPath
+		for _, arg := range x.args {
+			paths = append(paths, operandPaths(arg)...)
+		}
+		return paths
+	}
+	return nil
+}
+
+// permits returns whether the path patterns of the policy permit evaluation
+// with ev.
+func (x *Expr) permits(ev *evaluation) bool {
+	if ev.modifies() {
+		return !x.noWrite
+	}
+	return !x.noRead
+}
+
+// permitsField returns whether the policy of ev, if any, permits the field
+// with index in struct type t.
+func (ev *evaluation) permitsField(t reflect.Type, index []int) bool {
+	if ev == nil || ev.policy == nil {
+		return true
+	}
+	p := ev.policy
+	f := t.FieldByIndex(index)
+	if p.ExportedOnly && !f.IsExported() {
+		return false
+	}
+	if p.Tag != "" {
+		if tag, ok := f.Tag.Lookup(p.Tag); !ok || tag == "-" {
+			return false
+		}
+	}
+	return true
+}
+
+// restrictsFields returns whether the policy of ev, if any, denies fields.
+func (ev *evaluation) restrictsFields() bool {
+	return ev != nil && ev.policy != nil && (ev.policy.ExportedOnly || ev.policy.Tag != "")
+}
+
+// leaves returns the values of track which are leaves, conform Flatten, when
+// seg is a recursive descent at the end of a path, and when the policy of ev
+// denies fields. Track is returned as is otherwise.
+func (ev *evaluation) leaves(track []reflect.Value, seg *segment) []reflect.Value {
+	if !seg.last || !ev.restrictsFields() {
+		return track
+	}
+	tracing := ev.tracing()
+	writeIndex := 0
+	for i, v := range track {
+		if isLeaf(v) {
+			track[writeIndex] = v
+			if tracing {
+				ev.hops[writeIndex] = ev.hops[i]
+			}
+			writeIndex++
+		}
+	}
+	if tracing {
+		ev.hops = ev.hops[:writeIndex]
+	}
+	return track[:writeIndex]
+}
+
+// isLeaf returns whether v is a leaf conform Flatten.
+func isLeaf(v reflect.Value) bool {
+	v = follow(v, false)
+	switch v.Kind() {
+	case reflect.Struct:
+		return !hasExported(v.Type())
+	case reflect.Slice:
+		return v.Type().Elem().Kind() == reflect.Uint8
+	case reflect.Array, reflect.Map:
+		return false
+	}
+	return true
+}
+
+// conceal returns v as is, unless the policy of ev denies fields and v is not
+// a leaf, conform Flatten. Such content is returned as a deep copy, with the
+// denied fields, and the non-exported fields, at their zero value.
+func (ev *evaluation) conceal(v reflect.Value) reflect.Value {
+	if !ev.restrictsFields() || !v.IsValid() || isLeaf(v) {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	deepCopy(c, v, make(map[visit]reflect.Value), nil)
+	ev.clearDenied(c, make(map[visit]bool))
+	return c
+}
+
+// clearDenied sets the fields in v, which must be settable, which are denied by
+// the policy of ev to their zero value, recursively. Embedded structs without a
+// tag pass, conform the promotion of their fields.
+func (ev *evaluation) clearDenied(v reflect.Value, seen map[visit]bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		key := visit{p: v.Pointer(), typ: v.Type()}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		ev.clearDenied(v.Elem(), seen)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		e := reflect.New(v.Elem().Type()).Elem()
+		e.Set(v.Elem())
+		ev.clearDenied(e, seen)
+		v.Set(e)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		key := visit{p: v.Pointer(), typ: v.Type()}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		e := reflect.New(v.Type().Elem()).Elem()
+		for iter := v.MapRange(); iter.Next(); {
+			e.Set(iter.Value())
+			ev.clearDenied(e, seen)
+			v.SetMapIndex(iter.Key(), e)
+		}
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		key := visit{p: v.Pointer(), n: v.Len(), typ: v.Type()}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		fallthrough
+	case reflect.Array:
+		for i, n := 0, v.Len(); i < n; i++ {
+			ev.clearDenied(v.Index(i), seen)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		c := reflect.New(t).Elem()
+		for i, n := 0, t.NumField(); i < n; i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			promotes := f.Anonymous && ft.Kind() == reflect.Struct && f.Tag.Get(ev.policy.Tag) == ""
+			if !promotes && !ev.permitsField(t, []int{i}) {
+				continue
+			}
+			c.Field(i).Set(v.Field(i))
+			ev.clearDenied(c.Field(i), seen)
+		}
+		v.Set(c)
+	}
+}
+
+// deny marks the evaluation as a policy violation.
+func (ev *evaluation) deny() {
+	if ev != nil && ev.budget != nil {
+		ev.budget.denied = true
+	}
+}
@@ -0,0 +1,234 @@
+package el
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+var testCaches = []*Cache{
+	{TTL: 60, Size: 9, Plain: "p1", private: "x1", Labels: map[string]string{"env": "prod"}, Meta: &Meta{Owner: "ops", Version: 2}, meta: meta{Region: "eu"}},
+	{TTL: 90, Size: 3, Plain: "p2", private: "x2", Meta: &Meta{Owner: "dev"}},
+}
+
+func TestPolicyFields(t *testing.T) {
+	golden := []struct {
+		policy Policy
+		expr   string
+		want   []interface{}
+	}{
+		{Policy{}, "/.[0]/private", []interface{}{"x1"}},
+		{Policy{ExportedOnly: true}, "/.[0]/private", nil},
+		{Policy{ExportedOnly: true}, "/.[0]/Plain", []interface{}{"p1"}},
+		{Policy{ExportedOnly: true}, "/.[1]/*", []interface{}{int64(90), int64(3), "p2", Meta{Owner: "dev"}}},
+		{Policy{ExportedOnly: true}, `/.[?(/private == "x1")]/Plain`, nil},
+		{Policy{ExportedOnly: true}, "len(/.[0]/private)", nil},
+		{Policy{Tag: "json"}, "/.[0]/TTL", []interface{}{int64(60)}},
+		{Policy{Tag: "json"}, "/.[0]/Size", nil},
+		{Policy{Tag: "json"}, "/.[0]/Plain", nil},
+		{Policy{Tag: "json"}, "/.[0]/*", []interface{}{int64(60), map[string]string{"env": "prod"}}},
+		{Policy{Tag: "json"}, "/.[0]/@json:*", []interface{}{int64(60), map[string]string{"env": "prod"}, "ops", int64(2), "eu"}},
+		{Policy{Tag: "json"}, "/.[*]/@json:cache_ttl", []interface{}{int64(60), int64(90)}},
+		// content with denied fields
+		{Policy{ExportedOnly: true}, "/.[0]", []interface{}{Cache{TTL: 60, Size: 9, Plain: "p1", Labels: map[string]string{"env": "prod"}, Meta: &Meta{Owner: "ops", Version: 2}}}},
+		{Policy{Tag: "json"}, "/.[0]", []interface{}{Cache{TTL: 60, Labels: map[string]string{"env": "prod"}, Meta: &Meta{Owner: "ops", Version: 2}}}},
+		{Policy{Tag: "json"}, "/.", []interface{}{[]*Cache{{TTL: 60, Labels: map[string]string{"env": "prod"}, Meta: &Meta{Owner: "ops", Version: 2}}, {TTL: 90, Meta: &Meta{Owner: "dev"}}}}},
+	}
+	for _, gold := range golden {
+		x, err := gold.policy.Compile(gold.expr)
+		if err != nil {
+			t.Fatalf("compile %q error: %s", gold.expr, err)
+		}
+		got := x.Any(testCaches)
+		if gold.want == nil {
+			if got != nil {
+				t.Errorf("%+v: %q got %v, want none", gold.policy, gold.expr, got)
+			}
+			continue
+		}
+		verify.Values(t, gold.expr, got, gold.want)
+	}
+
+	x, err := Policy{ExportedOnly: true}.Compile("/.[1]")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	verify.Values(t, "locate", x.Locate(testCaches), []Match{{Path: "/.[1]", Value: Cache{TTL: 90, Size: 3, Plain: "p2", Meta: &Meta{Owner: "dev"}}}})
+	verify.Values(t, "original", testCaches[1].private, "x2")
+}
+
+func TestPolicyDescent(t *testing.T) {
+	x, err := Policy{Tag: "json"}.Compile("/.[0]/**")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	for _, s := range x.Strings(testCaches) {
+		if s == "p1" || s == "x1" {
+			t.Errorf("descent got denied content %q", s)
+		}
+	}
+	for _, m := range x.Locate(testCaches) {
+		switch m.Path {
+		case "/.[0]/Size", "/.[0]/Plain", "/.[0]/private":
+			t.Errorf("located denied content %q", m.Path)
+		}
+	}
+
+	// leaves only at the end
+	x, err = Policy{ExportedOnly: true}.Compile("/**")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	for _, v := range x.Any(testCaches) {
+		switch v.(type) {
+		case []*Cache, Cache, Meta, map[string]string:
+			t.Errorf("descent got %T, which may hold denied fields", v)
+		}
+	}
+	var paths []string
+	for _, m := range x.Locate(testCaches) {
+		paths = append(paths, m.Path)
+	}
+	want := []string{
+		"/.[0]/TTL", "/.[0]/Size", `/.[0]/Labels["env"]`, "/.[0]/Plain", "/.[0]/Meta/Owner", "/.[0]/Meta/Version",
+		"/.[1]/TTL", "/.[1]/Size", "/.[1]/Plain", "/.[1]/Meta/Owner", "/.[1]/Meta/Version",
+	}
+	verify.Values(t, "located", paths, want)
+	x, err = Policy{ExportedOnly: true}.Compile("/**/TTL")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	verify.Values(t, "TTLs", x.Ints(testCaches), []int64{60, 90})
+}
+
+func TestPolicyPatterns(t *testing.T) {
+	p := Policy{
+		Read:      []string{"/.[*]/Plain", "/.[*]/TTL", "/.[0]/Meta/**"},
+		ReadWrite: []string{"/.[*]/Labels", "/.[1]/Plain"},
+		Limits:    Limits{AllowCalls: true},
+	}
+	golden := []struct {
+		expr        string
+		read, write bool
+	}{
+		{"/.[0]/Plain", true, false},
+		{"/.[*]/Plain", true, false},
+		{"/.[?(/TTL == 90)]/Plain", true, false},
+		{"/.[?(/Size == 3)]/Plain", false, false},
+		{"/.[*]", false, false},
+		{"/.[1]", false, false},
+		{"/.[0]/Size", false, false},
+		{"/**", false, false},
+		{"/.[0]/Labels", true, true},
+		{`/.[0]/Labels["env"]`, true, true},
+		{`/.[?(/TTL == 60)]/Labels["env"]`, true, true},
+		{`/.[?(/Size == 9)]/Labels["env"]`, false, false},
+		{"/.[1]/Plain", true, true},
+		{"/.[1]/@json:cache_ttl", false, false},
+		{"/.[0]/Meta", false, false},
+		{"/.[0]/Meta/**", true, false},
+		{"/.[0]/Meta/Owner", true, false},
+		{"/.[0]/Meta/String()", false, false},
+		{"/", false, false},
+		{"len(/.[*]/Plain)", true, false},
+		{"len(/.[*]/Plain) + len(/.[0]/private)", false, false},
+	}
+	for _, gold := range golden {
+		x, err := p.Compile(gold.expr)
+		if err != nil {
+			t.Fatalf("compile %q error: %s", gold.expr, err)
+		}
+		if got := !x.noRead; got != gold.read {
+			t.Errorf("%q got read permission %t, want %t", gold.expr, got, gold.read)
+		}
+		if got := !x.noWrite; got != gold.write {
+			t.Errorf("%q got write permission %t, want %t", gold.expr, got, gold.write)
+		}
+	}
+
+	if _, err := (Policy{Read: []string{"/.["}}).Compile("/"); err == nil {
+		t.Error("malformed pattern got no error")
+	}
+}
+
+func TestPolicyModification(t *testing.T) {
+	p := Policy{
+		ExportedOnly: true,
+		Read:         []string{"/.[*]/TTL"},
+		ReadWrite:    []string{"/.[*]/Plain", "/.[*]/private"},
+	}
+
+	r := []*Cache{{TTL: 60, Plain: "p1", private: "x1"}, {TTL: 90, Plain: "p2", private: "x2"}}
+	x, err := p.Compile("/.[*]/TTL")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	if got := x.Ints(r); len(got) != 2 {
+		t.Errorf("read got %d, want 2 values", got)
+	}
+	if n := x.Assign(r, 30); n != 0 {
+		t.Errorf("read-only assign got %d, want 0", n)
+	}
+	if n := x.Delete(r); n != 0 {
+		t.Errorf("read-only delete got %d, want 0", n)
+	}
+
+	x, err = p.Compile("/.[*]/Plain")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	if n := x.Assign(r, "x"); n != 2 {
+		t.Errorf("read-write assign got %d, want 2", n)
+	}
+
+	x, err = p.Compile("/.[*]/private")
+	if err != nil {
+		t.Fatal("compile error:", err)
+	}
+	if n := x.AssignText(r, "x"); n != 0 {
+		t.Errorf("assign of non-exported field got %d, want 0", n)
+	}
+	if n := x.Delete(r); n != 0 {
+		t.Errorf("delete of non-exported field got %d, want 0", n)
+	}
+
+	want := []*Cache{{TTL: 60, Plain: "x", private: "x1"}, {TTL: 90, Plain: "x", private: "x2"}}
+	verify.Values(t, "modified", r, want)
+}
+
+func TestPolicyMethods(t *testing.T) {
+	golden := []struct {
+		policy Policy
+		expr   string
+		want   []interface{}
+	}{
+		{Policy{}, "/Tags/Len()", []interface{}{int64(3)}},
+		{Policy{ExportedOnly: true}, "/Tags/Len()", nil},
+		{Policy{ExportedOnly: true}, "/Tags", []interface{}{tagList{"a", "b", "c"}}},
+		{Policy{Read: []string{"/Tags"}}, "/Tags/Len()", nil},
+		{Policy{Read: []string{"/**"}}, "/Tags/Len()", nil},
+		{Policy{Read: []string{"/Tags[*]"}}, "/Tags[?(/Len() > 0)]", nil},
+		{Policy{Read: []string{"/Tags/Len()"}}, "/Tags/Len()", []interface{}{int64(3)}},
+		{Policy{Read: []string{"/Tags/Len()"}}, "/Tags/Last()", nil},
+		{Policy{ExportedOnly: true}, "len(/Tags/Last())", nil},
+	}
+	for _, gold := range golden {
+		gold.policy.Limits.AllowCalls = true
+		x, err := gold.policy.Compile(gold.expr)
+		if err != nil {
+			t.Fatalf("compile %q error: %s", gold.expr, err)
+		}
+		got := x.Any(testEndpoint)
+		if gold.want == nil {
+			if got != nil {
+				t.Errorf("%+v: %q got %v, want none", gold.policy, gold.expr, got)
+			}
+			continue
+		}
+		verify.Values(t, gold.expr, got, gold.want)
+	}
+
+	if _, err := (Policy{Read: []string{"/Tags/Len()"}}).Compile("/Tags/Len()"); err == nil {
+		t.Error("no compile error for method invocation without AllowCalls")
+	}
+}
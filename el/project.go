@@ -0,0 +1,337 @@
+package el
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Project returns a deep copy of root with only the content selected by the
+// paths, and the zero value for anything else. Intermediate content, like
+// pointers, maps and slices, is instantiated as far as needed to hold the
+// selections. Map entries keep their key and slice elements keep their
+// number, i.e., slices keep their length.
+//
+// Root is read only. Map entries with a key without a literal notation, and
+// the results of method invocations are not projected. Malformed paths and
+// expressions other than paths have no effect.
+func Project(root interface{}, paths ...string) interface{} {
+	v := reflect.ValueOf(root)
+	if !v.IsValid() {
+		return nil
+	}
+	c := reflect.New(v.Type()).Elem()
+	seen := make(map[visit]reflect.Value)
+	for _, p := range paths {
+		for _, steps := range projectSteps(p, v) {
+			project(c, v, steps, seen)
+		}
+	}
+	return c.Interface()
+}
+
+// ProjectMap is like Project, with a tree of map[string]interface{} for the
+// structs and the maps, and []interface{} for the slices and the arrays on the
+// way to a selection. The names conform encoding/json, which makes the result
+// a partial JSON representation of root. Elements which are not selected are
+// nil, and map entries with a key without a JSON notation are omitted. The
+// selections themselves are deep copies as is. The return is nil when nothing
+// matched.
+func ProjectMap(root interface{}, paths ...string) interface{} {
+	v := reflect.ValueOf(root)
+	var tree interface{}
+	seen := make(map[visit]reflect.Value)
+	for _, p := range paths {
+		for _, steps := range projectSteps(p, v) {
+			tree = projectTree(tree, v, steps, seen, nil)
+		}
+	}
+	return tree
+}
+
+// ProjectMapExpr is like ProjectMap, with compiled paths, such that the limits
+// and the policy of each apply. Paths which are not permitted by their policy
+// have no effect. The error reports an expression which is not a path, or one
+// which ran out of its budget.
+func ProjectMapExpr(root interface{}, paths ...*Expr) (interface{}, error) {
+	v := reflect.ValueOf(root)
+	var tree interface{}
+	seen := make(map[visit]reflect.Value)
+	for _, x := range paths {
+		if x.x != nil {
+			return nil, fmt.Errorf("goe el: expression %q is not a path", x.src)
+		}
+		ev := x.constrain(nil)
+		if !x.permits(ev) {
+			continue
+		}
+		a := pathSteps(x.path, v, ev)
+		if ev.voided() {
+			if ev.budget.out {
+				return nil, fmt.Errorf("goe el: expression %q exceeds the limits", x.src)
+			}
+			continue
+		}
+		for _, steps := range a {
+			tree = projectTree(tree, v, steps, seen, ev)
+		}
+	}
+	return tree, nil
+}
+
+// projectStep is either a field selection by name or a key selection with a
+// literal.
+type projectStep struct {
+	seg *segment
+	key bool
+}
+
+// projectSteps returns the canonical location of each match of expr on root.
+func projectSteps(expr string, root reflect.Value) [][]projectStep {
+	path, err := parsePath(expr)
+	if err != nil {
+		return nil
+	}
+	return pathSteps(path, root, nil)
+}
+
+// pathSteps returns the canonical location of each match of path on root, with
+// ev for the budget and the policy, if any.
+func pathSteps(path []segment, root reflect.Value, ev *evaluation) [][]projectStep {
+	var a [][]projectStep
+	for _, l := range locateValues(path, root, ev) {
+		if l.path == "" {
+			a = append(a, nil) // root
+			continue
+		}
+		if strings.Contains(l.path, "[*]") {
+			continue // key without literal
+		}
+		canon, err := parsePath(l.path)
+		if err != nil {
+			continue
+		}
+
+		var steps []projectStep
+		for i := range canon {
+			seg := &canon[i]
+			if seg.call {
+				steps = nil
+				break
+			}
+			if seg.selection != "" {
+				steps = append(steps, projectStep{seg: seg})
+			}
+			if seg.key != "" {
+				steps = append(steps, projectStep{seg: seg, key: true})
+			}
+		}
+		if steps != nil {
+			a = append(a, steps)
+		}
+	}
+	return a
+}
+
+// project sets the content of src at the location of steps in dst, which must
+// be of the same type.
+func project(dst, src reflect.Value, steps []projectStep, seen map[visit]reflect.Value) {
+	if !dst.CanSet() {
+		return
+	}
+	if len(steps) == 0 {
+		deepCopy(dst, src, seen, nil)
+		return
+	}
+
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(src.Type().Elem()))
+		}
+		project(dst.Elem(), src.Elem(), steps, seen)
+		return
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		e := src.Elem()
+		c := reflect.New(e.Type()).Elem()
+		if !dst.IsNil() && dst.Elem().Type() == e.Type() {
+			c.Set(dst.Elem())
+		}
+		project(c, e, steps, seen)
+		dst.Set(c)
+		return
+	}
+
+	step := steps[0]
+	if !step.key {
+		if src.Kind() != reflect.Struct {
+			return
+		}
+		f, ok := src.Type().FieldByName(step.seg.selection)
+		if !ok || len(f.Index) != 1 {
+			return
+		}
+		project(dst.Field(f.Index[0]), src.Field(f.Index[0]), steps[1:], seen)
+		return
+	}
+
+	switch src.Kind() {
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		}
+		fallthrough
+	case reflect.Array:
+		for _, i := range step.seg.elementIndices(src, nil) {
+			project(dst.Index(i), src.Index(i), steps[1:], seen)
+		}
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(src.Type()))
+		}
+		for _, k := range step.seg.matchKeys(src, nil) {
+			e := src.MapIndex(k)
+			if !e.IsValid() {
+				continue
+			}
+			c := reflect.New(e.Type()).Elem()
+			if x := dst.MapIndex(k); x.IsValid() {
+				c.Set(x)
+			}
+			project(c, e, steps[1:], seen)
+			dst.SetMapIndex(k, c)
+		}
+	}
+}
+
+// projectTree returns node with the content of src at the location of steps.
+// The policy of ev, if any, applies to the content conform conceal.
+func projectTree(node interface{}, src reflect.Value, steps []projectStep, seen map[visit]reflect.Value, ev *evaluation) interface{} {
+	if len(steps) == 0 {
+		if !src.IsValid() {
+			return nil
+		}
+		if ev.restrictsFields() && !isLeaf(src) {
+			return ev.conceal(src).Interface()
+		}
+		c := reflect.New(src.Type()).Elem()
+		deepCopy(c, src, seen, nil)
+		return c.Interface()
+	}
+
+	for src.Kind() == reflect.Ptr || src.Kind() == reflect.Interface {
+		if src.IsNil() {
+			return node
+		}
+		src = src.Elem()
+	}
+
+	step := steps[0]
+	if !step.key {
+		if src.Kind() != reflect.Struct {
+			return node
+		}
+		f, ok := src.Type().FieldByName(step.seg.selection)
+		if !ok || len(f.Index) != 1 || !f.IsExported() {
+			return node
+		}
+		name := jsonTagName(f)
+		if name == "-" {
+			return node
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && name == "" {
+			// promoted fields
+			return projectTree(node, src.Field(f.Index[0]), steps[1:], seen, ev)
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		m, ok := treeObject(node)
+		if !ok {
+			return node // selected as a whole
+		}
+		m[name] = projectTree(m[name], src.Field(f.Index[0]), steps[1:], seen, ev)
+		return m
+	}
+
+	switch src.Kind() {
+	case reflect.Array, reflect.Slice:
+		var a []interface{}
+		switch node := node.(type) {
+		case nil:
+			a = make([]interface{}, src.Len())
+		case []interface{}:
+			a = node
+		default:
+			return node // selected as a whole
+		}
+		for _, i := range step.seg.elementIndices(src, nil) {
+			if i < len(a) {
+				a[i] = projectTree(a[i], src.Index(i), steps[1:], seen, ev)
+			}
+		}
+		return a
+
+	case reflect.Map:
+		m, ok := treeObject(node)
+		if !ok {
+			return node // selected as a whole
+		}
+		for _, k := range step.seg.matchKeys(src, nil) {
+			e := src.MapIndex(k)
+			if !e.IsValid() {
+				continue
+			}
+			name, ok := wireKeyName(k)
+			if !ok {
+				continue
+			}
+			m[name] = projectTree(m[name], e, steps[1:], seen, ev)
+		}
+		return m
+	}
+	return node
+}
+
+// treeObject returns node as a map, with a new one for nil.
+func treeObject(node interface{}) (map[string]interface{}, bool) {
+	if node == nil {
+		return make(map[string]interface{}), true
+	}
+	m, ok := node.(map[string]interface{})
+	return m, ok
+}
+
+// wireKeyName returns the JSON name of map key k, if any.
+func wireKeyName(k reflect.Value) (string, bool) {
+	switch kindClass(k.Kind()) {
+	case reflect.String:
+		return k.String(), true
+	case reflect.Int:
+		return strconv.FormatInt(k.Int(), 10), true
+	case reflect.Uint:
+		return strconv.FormatUint(k.Uint(), 10), true
+	}
+	return "", false
+}
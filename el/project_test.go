@@ -0,0 +1,127 @@
+package el
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestProject(t *testing.T) {
+	orig := Flatten(testCluster)
+	got := Project(testCluster, "/Name", "/Nodes[1]/Host", `/Weights["b"]`, "/Cache/TTL", "/Absent", "/Name[", "len(/Nodes)")
+
+	verify.Values(t, "original", Flatten(testCluster), orig)
+
+	c, ok := got.(*cluster)
+	if !ok {
+		t.Fatalf("got type %T", got)
+	}
+	if c == testCluster || c.Nodes[1] == testCluster.Nodes[1] {
+		t.Fatal("projection shares content with the original")
+	}
+	want := &cluster{
+		Name:    "DB-7",
+		Nodes:   []*clusterNode{nil, {Host: "b"}, nil, nil},
+		Cache:   struct{ TTL int }{TTL: 90},
+		Weights: map[string]float64{"b": 1.5},
+	}
+	verify.Values(t, "projection", c, want)
+
+	// promoted fields and interfaces
+	verify.Values(t, "promoted", Project(testCaches, "/.[0]/Owner"), []*Cache{{Meta: &Meta{Owner: "ops"}}, nil})
+	x := &Node{X: map[string]interface{}{"x": map[string]interface{}{"y": 1, "z": 2}}}
+	verify.Values(t, "interface", Project(x, `/X/.["x"]/.["y"]`), &Node{X: map[string]interface{}{"x": map[string]interface{}{"y": 1}}})
+}
+
+func TestProjectWildcards(t *testing.T) {
+	got := Project(*testCluster, "/Cache", "/Cache/TTL", "/Nodes[*]", "/Weights[*]")
+
+	want := cluster{
+		Nodes:   testCluster.Nodes,
+		Cache:   testCluster.Cache,
+		Weights: testCluster.Weights,
+	}
+	verify.Values(t, "projection", got, want)
+
+	if got := Project(testCluster); got.(*cluster) != nil {
+		t.Errorf("got %+v without paths, want nil pointer", got)
+	}
+	verify.Values(t, "root", Project(testCluster, "/"), testCluster)
+	verify.Values(t, "nil", Project(nil, "/"), nil)
+}
+
+func TestProjectMap(t *testing.T) {
+	orig := Flatten(testCaches)
+	got := ProjectMap(testCaches, "/.[0]/TTL", "/.[0]/Owner", `/.[0]/Labels[*]`, "/.[1]/Plain", "/.[1]/Size", "/.[1]/private", "/Absent")
+
+	verify.Values(t, "original", Flatten(testCaches), orig)
+
+	want := []interface{}{
+		map[string]interface{}{
+			"cache_ttl": 60,
+			"owner":     "ops",
+			"labels":    map[string]interface{}{"env": "prod"},
+		},
+		map[string]interface{}{"Plain": "p2"},
+	}
+	verify.Values(t, "projection", got, want)
+
+	x := &Node{X: map[string]interface{}{"x": map[string]interface{}{"y": 1, "z": 2}}}
+	verify.Values(t, "interface", ProjectMap(x, `/X/.["x"]/.["z"]`), map[string]interface{}{
+		"X": map[string]interface{}{"x": map[string]interface{}{"z": 2}},
+	})
+}
+
+func TestProjectMapWhole(t *testing.T) {
+	got := ProjectMap(testCluster, "/Cache/TTL", "/Cache", "/Cache/TTL")
+
+	want := map[string]interface{}{
+		"Cache": struct{ TTL int }{TTL: 90},
+	}
+	verify.Values(t, "projection", got, want)
+
+	verify.Values(t, "no match", ProjectMap(testCluster, "/Absent"), nil)
+}
+
+func TestProjectMapExpr(t *testing.T) {
+	policy := Policy{ExportedOnly: true, Tag: "json", Limits: Limits{MaxResults: 4}}
+	var paths []*Expr
+	for _, s := range []string{"/.[*]/TTL", "/.[0]/Plain", "/.[0]/private"} {
+		x, err := policy.Compile(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, x)
+	}
+	got, err := ProjectMapExpr(testCaches, paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"cache_ttl": 60},
+		map[string]interface{}{"cache_ttl": 90},
+	}
+	verify.Values(t, "projection", got, want)
+
+	whole, err := policy.Compile("/.[1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = ProjectMapExpr(testCaches, whole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "denied fields", got, []interface{}{nil, &Cache{TTL: 90, Meta: &Meta{Owner: "dev"}}})
+
+	x, err := policy.Compile("/**")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const wantErr = `goe el: expression "/**" exceeds the limits`
+	if got, err := ProjectMapExpr(testCaches, x); err == nil || err.Error() != wantErr {
+		t.Errorf("got %v with error %v, want error %s", got, err, wantErr)
+	}
+	if _, err := ProjectMapExpr(nil, MustCompile("len(/.[*])")); err == nil {
+		t.Error("no error for expression which is not a path")
+	}
+}
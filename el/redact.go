@@ -0,0 +1,71 @@
+package el
+
+import (
+	"reflect"
+)
+
+// Redact returns a deep copy of root with the content at each path set to its
+// zero value. The structure remains as is, i.e., map entries and slice
+// elements are blanked rather than removed. Pointers are followed such that
+// the content they point to is redacted.
+//
+// Root is read only, which makes it safe to share with other goroutines, as
+// long as none of them modifies it. Non-exported fields are copied as is, and
+// they can not be redacted therefore. Malformed paths and expressions other
+// than paths have no effect.
+func Redact(root interface{}, paths ...string) interface{} {
+	return RedactWith(root, nil, paths...)
+}
+
+// RedactWith is like Redact, with mask instead of the zero value on content
+// which mask is assignable, or convertible, to. A mask like "***" covers any
+// string, byte slice and interface, while numbers, for example, still go zero.
+func RedactWith(root interface{}, mask interface{}, paths ...string) interface{} {
+	v := reflect.ValueOf(root)
+	if !v.IsValid() {
+		return nil
+	}
+	c := reflect.New(v.Type()).Elem()
+	deepCopy(c, v, make(map[visit]reflect.Value), nil)
+
+	m := reflect.ValueOf(mask)
+	for _, expr := range paths {
+		path, err := parsePath(expr)
+		if err != nil {
+			continue
+		}
+		ev := new(evaluation)
+		for _, target := range resolveTargets(path, c, ev) {
+			redact(target, m)
+		}
+		ev.finish()
+	}
+	return c.Interface()
+}
+
+// redact sets v to mask, or to the zero value when mask does not fit.
+func redact(v, mask reflect.Value) {
+	if !v.IsValid() {
+		return
+	}
+	if mask.IsValid() {
+		switch t := v.Type(); {
+		case mask.Type().AssignableTo(t):
+			if v.CanSet() {
+				v.Set(mask)
+			}
+			return
+		case mask.Type().ConvertibleTo(t):
+			if v.CanSet() {
+				v.Set(mask.Convert(t))
+			}
+			return
+		}
+	}
+
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		redact(v.Elem(), mask)
+		return
+	}
+	zero(v)
+}
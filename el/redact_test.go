@@ -0,0 +1,99 @@
+package el
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestRedact(t *testing.T) {
+	orig := Flatten(testCluster)
+	got := Redact(testCluster, "/Cache/*", "/Nodes[*]/Host", "/Nodes[1]/Load", `/Weights["b"]`, "/Absent", "/Nodes[", "len(/Nodes)")
+
+	verify.Values(t, "original", Flatten(testCluster), orig)
+
+	c, ok := got.(*cluster)
+	if !ok {
+		t.Fatalf("got type %T", got)
+	}
+	if c == testCluster || c.Nodes[0] == testCluster.Nodes[0] {
+		t.Fatal("copy shares content with the original")
+	}
+	want := &cluster{
+		Name: "DB-7",
+		Nodes: []*clusterNode{
+			{Up: true, Load: 10},
+			{},
+			{Up: true, Load: 30},
+			{Up: true, Load: 50},
+		},
+		Weights: map[string]float64{"a": 0.5, "b": 0},
+		Limit:   100,
+		Primary: "a",
+		Replica: "b",
+	}
+	verify.Values(t, "redacted", c, want)
+
+	// pointers are followed
+	p := Redact(&testPV, "/SP").(*Ptrs)
+	if *p.SP != "" || p.SP == testPV.SP || testV.S != "32" {
+		t.Errorf("got %q at a copy %t, with original %q", *p.SP, p.SP != testPV.SP, testV.S)
+	}
+	// non-exported fields are copied as is
+	verify.Values(t, "non-exported", Redact(testCaches, "/.[*]/private", "/.[0]/Plain"), []*Cache{
+		{TTL: 60, Size: 9, private: "x1", Labels: map[string]string{"env": "prod"}, Meta: &Meta{Owner: "ops", Version: 2}, meta: meta{Region: "eu"}},
+		{TTL: 90, Size: 3, Plain: "p2", private: "x2", Meta: &Meta{Owner: "dev"}},
+	})
+}
+
+func TestRedactWith(t *testing.T) {
+	got := RedactWith(*testCluster, "***", "/Name", "/Nodes[*]/Host", "/Nodes[0]/Load", "/Weights[*]")
+
+	want := cluster{
+		Name: "***",
+		Nodes: []*clusterNode{
+			{Host: "***", Up: true},
+			{Host: "***", Load: 80},
+			{Host: "***", Up: true, Load: 30},
+			{Host: "***", Up: true, Load: 50},
+		},
+		Cache:   struct{ TTL int }{TTL: 90},
+		Weights: map[string]float64{"a": 0, "b": 0},
+		Limit:   100,
+		Primary: "a",
+		Replica: "b",
+	}
+	verify.Values(t, "redacted", got, want)
+
+	tests := []struct {
+		root interface{}
+		path string
+		want interface{}
+	}{
+		{map[string]string{"a": "1", "b": "2"}, `/.["a"]`, map[string]string{"a": "***", "b": "2"}},
+		{map[string][]byte{"a": []byte("1")}, `/.["a"]`, map[string][]byte{"a": []byte("***")}},
+		{&Node{S: []interface{}{1, "x"}}, "/S[*]", &Node{S: []interface{}{"***", "***"}}},
+		{testV, "/I", Vals{B: true, U: 4, F: 8, C: 16i, S: "32"}},
+		{nil, "/X", nil},
+	}
+	for _, test := range tests {
+		verify.Values(t, test.path, RedactWith(test.root, "***", test.path), test.want)
+	}
+}
+
+func TestRedactConcurrent(t *testing.T) {
+	orig := Flatten(testCluster)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				RedactWith(testCluster, "***", "/Nodes[*]/Host", "/Weights[*]", "/Cache/*")
+			}
+		}()
+	}
+	wg.Wait()
+	verify.Values(t, "shared", Flatten(testCluster), orig)
+}
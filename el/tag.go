@@ -0,0 +1,334 @@
+package el
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// parseTagSelection interprets the "@" notation of the selection.
+//
+//	tag-selection ::= "@" tag-key ":" tag-name
+//	tag-name      ::= "*" | go-string-literal | go-field-name
+func (seg *segment) parseTagSelection() error {
+	s := seg.selection[1:]
+	i := strings.IndexByte(s, ':')
+	if i <= 0 || i == len(s)-1 {
+		return fmt.Errorf("goe el: tag selection %q is not in the form @key:name", seg.selection)
+	}
+	seg.tag, seg.selection = s[:i], s[i+1:]
+
+	switch seg.selection[0] {
+	case '"', '`':
+		name, err := strconv.Unquote(seg.selection)
+		if err != nil {
+			return fmt.Errorf("goe el: tag selection @%s: name %s: %w", seg.tag, seg.selection, err)
+		}
+		seg.selection = name
+	case '*':
+		if seg.selection == "*" {
+			seg.tagAny = true
+		}
+	}
+	return nil
+}
+
+// selectionString returns the notation of the selection.
+func (seg *segment) selectionString() string {
+	switch {
+	case seg.call:
+		return seg.selection + "(" + strings.Join(seg.args, ", ") + ")"
+	case seg.tag == "" && seg.selection == "":
+		return "."
+	case seg.tag == "":
+		return seg.selection
+	case seg.tagAny:
+		return "@" + seg.tag + ":*"
+	default:
+		return "@" + seg.tag + ":" + quoteTagName(seg.selection)
+	}
+}
+
+// quoteTagName returns the tag-name notation of name. Names with characters
+// of the path or the expression syntax, white space or non-graphic runes need
+// quotes.
+func quoteTagName(name string) string {
+	if name == "" || name == "*" || strings.ContainsAny(name, "/[]()\"`<>=!&|") {
+		return strconv.Quote(name)
+	}
+	for _, r := range name {
+		if unicode.IsSpace(r) || !unicode.IsGraphic(r) {
+			return strconv.Quote(name)
+		}
+	}
+	return name
+}
+
+// followTagged returns all content matching the tag selection of seg from
+// track. Struct fields go by their tag name. Map entries go by their key, and
+// elements in arrays and slices go by their index, both in decimal notation.
+func followTagged(track []reflect.Value, seg *segment, ev *evaluation) []reflect.Value {
+	tracing := ev.tracing()
+	var dst []reflect.Value
+	for from, v := range track {
+		v := follow(v, ev.builds())
+		switch v.Kind() {
+		case reflect.Struct:
+			if seg.tagAny {
+				for _, f := range wireFields(v.Type(), seg.tag) {
+					if !ev.permitsField(v.Type(), f.index) {
+						continue
+					}
+					if e := fieldByIndex(v, f.index, ev.builds()); e.IsValid() {
+						dst = append(dst, e)
+						if tracing {
+							ev.hop(from, fieldLocation(v.Type(), f.index))
+						}
+					}
+				}
+			} else if index := seg.fieldIndex(v.Type()); index != nil {
+				if !ev.permitsField(v.Type(), index) {
+					ev.deny()
+					return nil
+				}
+				if e := fieldByIndex(v, index, ev.builds()); e.IsValid() {
+					dst = append(dst, e)
+					if tracing {
+						ev.hop(from, fieldLocation(v.Type(), index))
+					}
+				}
+			}
+
+		case reflect.Map:
+			var keys []reflect.Value
+			if seg.tagAny {
+				keys = v.MapKeys()
+				if tracing {
+					sortKeys(keys)
+				}
+			} else if key := wireKey(seg.selection, v.Type().Key()); key.IsValid() {
+				keys = []reflect.Value{key}
+			}
+			for _, key := range keys {
+				offset := len(dst)
+				dst = append(dst, reflect.Value{})
+				writeIndex := offset
+				followMap(dst, &writeIndex, v, key, ev)
+				dst = dst[:writeIndex]
+				if tracing && writeIndex != offset {
+					ev.hop(from, "["+keyLiteral(key)+"]")
+				}
+			}
+
+		case reflect.Array, reflect.Slice:
+			if seg.tagAny {
+				for i, n := 0, v.Len(); i < n; i++ {
+					dst = append(dst, v.Index(i))
+					if tracing {
+						ev.hop(from, "["+strconv.Itoa(i)+"]")
+					}
+				}
+				continue
+			}
+
+			i, ok := wireIndex(seg.selection)
+			if !ok {
+				continue
+			}
+			if i >= v.Len() {
+				if v.Kind() != reflect.Slice || !v.CanSet() || !ev.builds() {
+					continue
+				}
+				n := i - v.Len() + 1
+				if !ev.grows(n) {
+					continue
+				}
+				v.Set(reflect.AppendSlice(v, reflect.MakeSlice(v.Type(), n, n)))
+			}
+			dst = append(dst, v.Index(i))
+			if tracing {
+				ev.hop(from, "["+strconv.Itoa(i)+"]")
+			}
+
+		}
+	}
+	return dst
+}
+
+// wireKey returns the map key for name, with an invalid value for none.
+// String and integer types are supported, conform encoding/json.
+func wireKey(name string, t reflect.Type) reflect.Value {
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(name).Convert(t)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(name, 10, t.Bits())
+		if err == nil {
+			return reflect.ValueOf(i).Convert(t)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(name, 10, t.Bits())
+		if err == nil {
+			return reflect.ValueOf(u).Convert(t)
+		}
+	}
+	return reflect.Value{}
+}
+
+// wireIndex returns the element number for name, if any. Only the canonical
+// decimal notation is accepted, i.e., without any leading zeros.
+func wireIndex(name string) (int, bool) {
+	if name == "" || len(name) > 1 && name[0] == '0' {
+		return 0, false
+	}
+	i, err := strconv.ParseUint(name, 10, 31)
+	if err != nil {
+		return 0, false
+	}
+	return int(i), true
+}
+
+// wireField is a struct field with a tag name.
+type wireField struct {
+	name  string
+	index []int
+}
+
+// wireFieldsKey identifies a wireFields result.
+type wireFieldsKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// wireFieldsCache has the wireFields per wireFieldsKey.
+var wireFieldsCache sync.Map
+
+// wireFields returns the fields of struct type t in order of appearance. Names
+// come from the struct tag with key, like "json", before any options (after the
+// comma). Fields without such tag go by their name in Go. Exclusion with "-" is
+// honored, and so is the promotion of fields from embedded structs, conform the
+// rules of encoding/json.
+func wireFields(t reflect.Type, key string) []wireField {
+	cacheKey := wireFieldsKey{t, key}
+	if fields, ok := wireFieldsCache.Load(cacheKey); ok {
+		return fields.([]wireField)
+	}
+
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+	type candidate struct {
+		index  []int
+		tagged bool
+	}
+
+	var fields []wireField
+	done := make(map[string]bool) // names dominated or ambiguous
+	visited := make(map[reflect.Type]bool)
+
+	// breadth-first such that shallow fields dominate
+	var current []embedded
+	next := []embedded{{t: t}}
+	for len(next) != 0 {
+		current, next = next, nil
+
+		var names []string // in order of appearance
+		candidates := make(map[string][]candidate)
+		for _, e := range current {
+			if visited[e.t] {
+				continue
+			}
+			visited[e.t] = true
+
+			for i, n := 0, e.t.NumField(); i < n; i++ {
+				f := e.t.Field(i)
+				ft := f.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if f.PkgPath != "" && !(f.Anonymous && ft.Kind() == reflect.Struct) {
+					continue // not exported
+				}
+
+				tag := f.Tag.Get(key)
+				if tag == "-" {
+					continue
+				}
+				if i := strings.IndexByte(tag, ','); i >= 0 {
+					tag = tag[:i]
+				}
+
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+
+				if tag == "" && f.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{ft, index})
+					continue
+				}
+				if f.PkgPath != "" {
+					continue // embedded struct with a name
+				}
+
+				name := tag
+				if name == "" {
+					name = f.Name
+				}
+				if done[name] {
+					continue
+				}
+				if _, ok := candidates[name]; !ok {
+					names = append(names, name)
+				}
+				candidates[name] = append(candidates[name], candidate{index, tag != ""})
+			}
+		}
+
+		for _, name := range names {
+			done[name] = true
+
+			a := candidates[name]
+			var tagged []candidate
+			for _, c := range a {
+				if c.tagged {
+					tagged = append(tagged, c)
+				}
+			}
+			switch {
+			case len(tagged) == 1:
+				fields = append(fields, wireField{name, tagged[0].index})
+			case len(a) == 1:
+				fields = append(fields, wireField{name, a[0].index})
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	wireFieldsCache.Store(cacheKey, fields)
+	return fields
+}
+
+// taggedField returns the index sequence of the wireField with name, with nil
+// for no match.
+func taggedField(t reflect.Type, key, name string) []int {
+	for _, f := range wireFields(t, key) {
+		if f.name == name {
+			return f.index
+		}
+	}
+	return nil
+}
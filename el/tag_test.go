@@ -0,0 +1,141 @@
+package el
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+type Meta struct {
+	Owner   string `json:"owner"`
+	Version int    `json:"version,omitempty" yaml:"rev"`
+}
+
+type meta struct {
+	Region string `json:"region"`
+}
+
+type Conflict struct {
+	Version int `yaml:"rev"`
+}
+
+type Cache struct {
+	TTL     int               `json:"cache_ttl" yaml:"ttl"`
+	Size    int               `json:"-"`
+	Labels  map[string]string `json:"labels"`
+	Plain   string
+	private string
+	*Meta
+	meta
+}
+
+func TestTagSelections(t *testing.T) {
+	root := &Cache{
+		TTL: 60, Size: 9, Plain: "p", private: "x",
+		Labels: map[string]string{"env": "prod", "a/b": "c", "": "empty"},
+		Meta:   &Meta{Owner: "ops", Version: 2},
+		meta:   meta{Region: "eu"},
+	}
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"/@json:cache_ttl", []interface{}{int64(60)}},
+		{"/@yaml:ttl", []interface{}{int64(60)}},
+		{"/@json:TTL", []interface{}(nil)},
+		{"/@json:Size", []interface{}(nil)},
+		{"/@json:Plain", []interface{}{"p"}},
+		{"/@json:private", []interface{}(nil)},
+		{"/@json:owner", []interface{}{"ops"}},
+		{"/@json:version", []interface{}{int64(2)}},
+		{"/@yaml:rev", []interface{}{int64(2)}},
+		{"/@json:region", []interface{}{"eu"}},
+		{"/@json:labels/@json:env", []interface{}{"prod"}},
+		{`/@json:labels/@json:"a/b"`, []interface{}{"c"}},
+		{"/@json:labels/@json:mis", []interface{}(nil)},
+		{`/@json:labels[?. == "prod"]`, []interface{}{"prod"}},
+		{`/@json:labels/@json:""`, []interface{}{"empty"}},
+		{`/@json:*`, []interface{}{int64(60), root.Labels, "p", "ops", int64(2), "eu"}},
+	}
+	for _, test := range tests {
+		verify.Values(t, test.expr, Any(test.expr, root), test.want)
+	}
+}
+
+func TestTagSelectionWire(t *testing.T) {
+	root := &struct {
+		List  []string          `json:"list"`
+		Array [2]int            `json:"array"`
+		ByInt map[int8]string   `json:"by_int"`
+		ByUn  map[uint]struct{} `json:"by_uint"`
+	}{
+		List:  []string{"a", "b"},
+		Array: [2]int{1, 2},
+		ByInt: map[int8]string{-1: "neg"},
+	}
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"/@json:list/@json:1", []interface{}{"b"}},
+		{"/@json:list/@json:01", []interface{}(nil)},
+		{"/@json:list/@json:2", []interface{}(nil)},
+		{"/@json:list/@json:*", []interface{}{"a", "b"}},
+		{"/@json:array/@json:0", []interface{}{int64(1)}},
+		{"/@json:by_int/@json:-1", []interface{}{"neg"}},
+		{"/@json:by_int/@json:x", []interface{}(nil)},
+		{"/@json:by_int/@json:*", []interface{}{"neg"}},
+	}
+	for _, test := range tests {
+		verify.Values(t, test.expr, Any(test.expr, root), test.want)
+	}
+
+	if n := Assign(root, "/@json:list/@json:3", "d"); n != 1 {
+		t.Errorf("got %d updates on slice growth, want 1", n)
+	}
+	verify.Values(t, "grown slice", root.List, []string{"a", "b", "", "d"})
+	if n := Assign(root, "/@json:by_uint/@json:7", struct{}{}); n != 1 {
+		t.Errorf("got %d updates on map, want 1", n)
+	}
+	verify.Values(t, "map", root.ByUn, map[uint]struct{}{7: {}})
+}
+
+func TestTagSelectionDominance(t *testing.T) {
+	x := struct {
+		Meta
+		Conflict
+	}{Meta{Version: 1}, Conflict{Version: 2}}
+	if got, ok := Int("/@yaml:rev", x); ok {
+		t.Errorf("ambiguous selection got %d", got)
+	}
+
+	y := struct {
+		Meta
+		Version int `yaml:"rev"`
+	}{Meta{Version: 1}, 2}
+	if got, ok := Int("/@yaml:rev", y); !ok || got != 2 {
+		t.Errorf("got %d, %t, want shallow field 2", got, ok)
+	}
+}
+
+func TestTagSelectionAssign(t *testing.T) {
+	x := new(Cache)
+	if n := Assign(x, "/@json:owner", "dev"); n != 1 {
+		t.Errorf("got %d updates through nil embedded pointer, want 1", n)
+	}
+	if n := Assign(x, `/@json:labels/@json:"team"`, "core"); n != 1 {
+		t.Errorf("got %d updates on map, want 1", n)
+	}
+	verify.Values(t, "owner", x.Meta, &Meta{Owner: "dev"})
+	verify.Values(t, "labels", x.Labels, map[string]string{"team": "core"})
+}
+
+func TestTagSelectionCompileErrors(t *testing.T) {
+	for _, expr := range []string{"/@json", "/@json:", "/@:x", `/@json:"x`} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("compile %q: no error", expr)
+		}
+	}
+}
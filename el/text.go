@@ -0,0 +1,123 @@
+package el
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+	int64Type           = reflect.TypeOf(int64(0))
+	uint64Type          = reflect.TypeOf(uint64(0))
+	float64Type         = reflect.TypeOf(float64(0))
+)
+
+// AssignText is like Assign, but it parses text into the type of each target.
+// Types which implement encoding.TextUnmarshaler, with a pointer receiver or
+// otherwise, decode themselves. This includes time.Time, with RFC 3339. Numbers
+// follow the notation of key selection literals, which includes the base
+// prefixes of Go, and they must fit the target. Booleans go by strconv.ParseBool,
+// and time.Duration goes by time.ParseDuration. Byte slices and strings get the
+// text as is, and so do empty interfaces. Targets which fail to parse text
+// remain untouched.
+func AssignText(root interface{}, path string, text string) (n int) {
+	ev := &evaluation{build: true}
+	return assignText(eval(path, root, ev), ev, text)
+}
+
+// assignText applies text to each of the values.
+func assignText(values []reflect.Value, ev *evaluation, text string) (n int) {
+	for _, v := range values {
+		if !v.CanSet() {
+			continue
+		}
+		if w := parseText(text, v.Type()); w.IsValid() {
+			v.Set(w)
+			n++
+		}
+	}
+
+	ev.finish()
+	return n
+}
+
+// parseText returns the interpretation of text as a t, with the zero Value for
+// failure.
+func parseText(text string, t reflect.Type) reflect.Value {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		p := reflect.New(t)
+		if p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)) != nil {
+			return reflect.Value{}
+		}
+		return p.Elem()
+	}
+	if t == durationType {
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return reflect.Value{}
+		}
+		return reflect.ValueOf(d)
+	}
+
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(text)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return reflect.Value{}
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p := parseNumber(text, int64Type)
+		if p == nil || v.OverflowInt(p.Int()) {
+			return reflect.Value{}
+		}
+		v.SetInt(p.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		p := parseNumber(text, uint64Type)
+		if p == nil || v.OverflowUint(p.Uint()) {
+			return reflect.Value{}
+		}
+		v.SetUint(p.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		p := parseNumber(text, float64Type)
+		if p == nil || v.OverflowFloat(p.Float()) {
+			return reflect.Value{}
+		}
+		v.SetFloat(p.Float())
+
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return reflect.Value{}
+		}
+		v.SetBytes([]byte(text))
+
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return reflect.Value{}
+		}
+		v.Set(reflect.ValueOf(text))
+
+	default:
+		return reflect.Value{}
+	}
+	return v
+}
+
+// parseNumber returns the interpretation of text as a number literal of type t,
+// with nil for failure, including empty text.
+func parseNumber(text string, t reflect.Type) *reflect.Value {
+	if text == "" {
+		return nil
+	}
+	return parseLiteral(text, t)
+}
@@ -0,0 +1,84 @@
+package el
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+type settings struct {
+	Port    uint16
+	Offset  int8
+	Ratio   float32
+	Verbose bool
+	Timeout time.Duration
+	Start   time.Time
+	Addr    netip.Addr
+	Name    string
+	Key     []byte
+	Extra   interface{}
+	Level   *int
+	Labels  map[string]string
+	Codes   []int
+}
+
+func TestAssignText(t *testing.T) {
+	tests := []struct {
+		path, text string
+		want       int
+		set        func(*settings)
+	}{
+		{"/Port", "8080", 1, func(s *settings) { s.Port = 8080 }},
+		{"/Port", "0x1F", 1, func(s *settings) { s.Port = 31 }},
+		{"/Port", "'a'", 1, func(s *settings) { s.Port = 97 }},
+		{"/Port", "65536", 0, nil},
+		{"/Port", "-1", 0, nil},
+		{"/Port", "80 ", 0, nil},
+		{"/Port", "", 0, nil},
+		{"/Offset", "-128", 1, func(s *settings) { s.Offset = -128 }},
+		{"/Offset", "128", 0, nil},
+		{"/Ratio", "0.25", 1, func(s *settings) { s.Ratio = 0.25 }},
+		{"/Ratio", "1e39", 0, nil},
+		{"/Ratio", "", 0, nil},
+		{"/Verbose", "true", 1, func(s *settings) { s.Verbose = true }},
+		{"/Verbose", "1", 1, func(s *settings) { s.Verbose = true }},
+		{"/Verbose", "yes", 0, nil},
+		{"/Timeout", "1m30s", 1, func(s *settings) { s.Timeout = 90 * time.Second }},
+		{"/Timeout", "90", 0, nil},
+		{"/Start", "2001-02-03T04:05:06Z", 1, func(s *settings) { s.Start = time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC) }},
+		{"/Start", "yesterday", 0, nil},
+		{"/Addr", "::1", 1, func(s *settings) { s.Addr = netip.IPv6Loopback() }},
+		{"/Addr", "localhost", 0, nil},
+		{"/Name", "x/y", 1, func(s *settings) { s.Name = "x/y" }},
+		{"/Name", "", 1, nil},
+		{"/Key", "secret", 1, func(s *settings) { s.Key = []byte("secret") }},
+		{"/Extra", "any", 1, func(s *settings) { s.Extra = "any" }},
+		{"/Level", "3", 1, func(s *settings) { s.Level = new(int); *s.Level = 3 }},
+		{`/Labels["env"]`, "prod", 1, func(s *settings) { s.Labels = map[string]string{"env": "prod"} }},
+		{"/Codes[1]", "7", 1, func(s *settings) { s.Codes = []int{0, 7} }},
+		{"/Labels", "x", 0, nil},
+	}
+
+	for _, test := range tests {
+		got := new(settings)
+		if n := AssignText(got, test.path, test.text); n != test.want {
+			t.Errorf("%s %q: got %d assignments, want %d", test.path, test.text, n, test.want)
+		}
+		want := new(settings)
+		if test.set != nil {
+			test.set(want)
+		}
+		verify.Values(t, test.path+" "+test.text, got, want)
+	}
+}
+
+func TestAssignTextCompiled(t *testing.T) {
+	x := MustCompile("/Codes[*]")
+	s := &settings{Codes: []int{1, 2, 3}}
+	if n := x.AssignText(s, "-9"); n != 3 {
+		t.Errorf("got %d assignments, want 3", n)
+	}
+	verify.Values(t, "codes", s.Codes, []int{-9, -9, -9})
+}
@@ -140,8 +140,18 @@ func (repo *CRUDRepo) setDataType(t reflect.Type) {
 	case nil:
 		repo.dataType = t
 
-		if n := el.Assign(reflect.New(t).Interface(), repo.versionPath, 99); n != 1 {
-			log.Panicf("goe rest: version path %q matches %d element on type %s", repo.versionPath, n, t)
+		target, err := el.CheckTarget(repo.versionPath, t)
+		if err != nil {
+			log.Panicf("goe rest: version path on type %s: %s", t, err)
+		}
+		for target.Kind() == reflect.Ptr {
+			target = target.Elem()
+		}
+		switch target.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			break
+		default:
+			log.Panicf("goe rest: version path %q on type %s selects %s, which is not an integer", repo.versionPath, t, target)
 		}
 	case t:
 		// do nothing
@@ -152,6 +162,7 @@ func (repo *CRUDRepo) setDataType(t reflect.Type) {
 
 // ServeHTTP honors the http.Handler interface for the mount point provided with NewCRUD.
 // For now only JSON is supported.
+// Reads serve partial content with a "fields" query conform ServePartialJSON.
 func (repo *CRUDRepo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	p := path.Clean(r.URL.Path)
 	if !strings.HasPrefix(p, repo.mountLoc) {
@@ -326,7 +337,7 @@ func (repo *CRUDRepo) serveRead(w http.ResponseWriter, r *http.Request, id int64
 	h.Set("Last-Modified", timestamp.In(time.UTC).Format(time.RFC1123))
 
 	if r.Method != "HEAD" {
-		ServeJSON(w, http.StatusOK, result[0].Interface())
+		ServePartialJSON(w, r, http.StatusOK, result[0].Interface())
 	}
 }
 
@@ -91,6 +91,40 @@ var goldenCases = []struct {
 		},
 	},
 
+	// Partial content:
+	{"read fields",
+		"GET", "/99?fields=/Msg", "", nil,
+		200, "{\n\t\"msg\": \"hello\"\n}\n", map[string]string{
+			"ETag":         `"1456260879956532222"`,
+			"Content-Type": "application/json;charset=UTF-8",
+		},
+		func(id, version int64) (*Data, error) {
+			return &Data{1456260879956532222, "hello"}, nil
+		},
+	},
+	{"read fields mismatch",
+		"GET", "/99?fields=/Msg,/Mis", "", nil,
+		400, "", nil,
+		func(id, version int64) (*Data, error) {
+			return &Data{1456260879956532222, "hello"}, nil
+		},
+	},
+
+	{"read fields method",
+		"GET", "/99?fields=/Msg,/Reset()", "", nil,
+		400, "fields query \"/Reset()\": goe el: expression \"/Reset()\" has a method invocation, which the limits do not allow\n", nil,
+		func(id, version int64) (*Data, error) {
+			return &Data{1456260879956532222, "hello"}, nil
+		},
+	},
+	{"read fields length",
+		"GET", "/99?fields=/Msg" + strings.Repeat("/.", 512), "", nil,
+		400, "", nil,
+		func(id, version int64) (*Data, error) {
+			return &Data{1456260879956532222, "hello"}, nil
+		},
+	},
+
 	// Caching:
 	{"read cache miss",
 		"GET", "/99", "", map[string]string{"If-None-Match": `"1456249153812139289"`, "If-Modified-Since": "Tue, 23 Feb 2016 20:54:39 UTC"},
@@ -257,3 +291,17 @@ func TestGolden(t *testing.T) {
 		}
 	}
 }
+
+func TestVersionPath(t *testing.T) {
+	for _, path := range []string{"/Msg", "/Absent", "/*", "/Version/X"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("version path %q: no panic", path)
+				}
+			}()
+			NewCRUD("/", path).SetReadFunc(func(id, version int64) (*Data, error) { return nil, nil })
+		}()
+	}
+	NewCRUD("/", "/Version").SetReadFunc(func(id, version int64) (*Data, error) { return nil, nil })
+}
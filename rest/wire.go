@@ -2,11 +2,15 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"mime"
 	"net/http"
+	"reflect"
 	"strconv"
+
+	"github.com/pascaldekloe/goe/el"
 )
 
 var tailJSON = []byte{'\n'}
@@ -33,6 +37,63 @@ func ServeJSON(w http.ResponseWriter, statusCode int, src interface{}) {
 	}
 }
 
+// PartialLimits bounds the "fields" query of ServePartialJSON.
+var PartialLimits = el.Limits{MaxLength: 1024, MaxDepth: 32, MaxResults: 10000}
+
+// ServePartialJSON is like ServeJSON, with only the fields from the request
+// query, if any. The query parameter "fields" has comma separated GoEL paths,
+// e.g., "fields=/Name,/Address/City". Commas within quotes, brackets and
+// parentheses are part of the path, as in `/Labels["a,b"]`. The parameter may
+// repeat too. Paths select by Go field name, yet the selection is served
+// conform el.ProjectMap, i.e., with the JSON names of encoding/json, and with
+// an empty object when nothing matched. Use "@json:" selections, such as
+// "/@json:name", to select by JSON name instead. The paths are untrusted.
+// Only exported fields with a JSON tag are available, methods are not, and
+// evaluation is bounded by PartialLimits. Paths which can not apply to src,
+// and paths which exceed the limits, get an HTTP 400.
+func ServePartialJSON(w http.ResponseWriter, r *http.Request, statusCode int, src interface{}) {
+	var paths []string
+	for _, s := range r.URL.Query()["fields"] {
+		for _, p := range splitPaths(s) {
+			if p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		ServeJSON(w, statusCode, src)
+		return
+	}
+
+	policy := el.Policy{ExportedOnly: true, Tag: "json", Limits: PartialLimits}
+	t := reflect.TypeOf(src)
+	exprs := make([]*el.Expr, len(paths))
+	for i, p := range paths {
+		x, err := policy.Compile(p)
+		if err == nil && !x.IsPath() {
+			err = errors.New("not a path")
+		}
+		if err == nil && t != nil {
+			err = x.Check(t)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("fields query %q: %s", p, err), http.StatusBadRequest)
+			return
+		}
+		exprs[i] = x
+	}
+
+	partial, err := el.ProjectMapExpr(src, exprs...)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("fields query: %s", err), http.StatusBadRequest)
+		return
+	}
+	if partial == nil {
+		partial = struct{}{}
+	}
+	ServeJSON(w, statusCode, partial)
+}
+
 // ReceiveJSON reads the HTTP request body.
 // When the return is false then w must be left as is.
 func ReceiveJSON(dst interface{}, r *http.Request, w http.ResponseWriter) bool {
@@ -52,3 +113,33 @@ func ReceiveJSON(dst interface{}, r *http.Request, w http.ResponseWriter) bool {
 
 	return true
 }
+
+// splitPaths returns the comma separated entries in s. Commas in quoted
+// literals, in brackets and in parentheses do not separate.
+func splitPaths(s string) []string {
+	var paths []string
+	var depth, offset int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'', '`':
+			quote := s[i]
+			for i++; i < len(s) && s[i] != quote; i++ {
+				if s[i] == '\\' && quote != '`' {
+					i++
+				}
+			}
+		case '[', '(':
+			depth++
+		case ']', ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				paths = append(paths, s[offset:i])
+				offset = i + 1
+			}
+		}
+	}
+	return append(paths, s[offset:])
+}
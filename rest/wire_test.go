@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pascaldekloe/goe/el"
+)
+
+func TestServePartialJSONLimits(t *testing.T) {
+	defer func(l el.Limits) { PartialLimits = l }(PartialLimits)
+	PartialLimits.MaxResults = 2
+
+	r := httptest.NewRequest("GET", "/?fields=/**", nil)
+	w := httptest.NewRecorder()
+	ServePartialJSON(w, r, http.StatusOK, &Data{Version: 1, Msg: "hello"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got HTTP %d, want HTTP %d", w.Code, http.StatusBadRequest)
+	}
+	const want = "fields query: goe el: expression \"/**\" exceeds the limits\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+func TestServePartialJSONFields(t *testing.T) {
+	src := &struct {
+		Msg    string            `json:"msg"`
+		Labels map[string]string `json:"labels"`
+	}{Msg: "hello", Labels: map[string]string{"a,b": "x", "c": "y"}}
+
+	query := url.Values{"fields": {`/Labels["a,b"],/@json:msg`, "/Labels[\"c\"]"}}
+	r := httptest.NewRequest("GET", "/?"+query.Encode(), nil)
+	w := httptest.NewRecorder()
+	ServePartialJSON(w, r, http.StatusOK, src)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got HTTP %d, want HTTP %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	const want = "{\n\t\"labels\": {\n\t\t\"a,b\": \"x\",\n\t\t\"c\": \"y\"\n\t},\n\t\"msg\": \"hello\"\n}\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}